@@ -3,9 +3,8 @@ package workspace
 import (
 	"fmt"
 	"github.com/pinealctx/renault/pkg/paths"
-	"github.com/pinealctx/renault/pkg/share"
 	"github.com/urfave/cli/v2"
-	"os"
+	"path"
 )
 
 var addCommand = &cli.Command{
@@ -23,18 +22,26 @@ var addCommand = &cli.Command{
 			Name:  "name",
 			Usage: "Specify the project name.",
 		},
+		&cli.StringFlag{
+			Name:  "path",
+			Usage: "Specify the project path relative to the workspace, eg: hermes/user.",
+		},
+		&cli.StringSliceFlag{
+			Name:    "group",
+			Aliases: []string{"g"},
+			Usage:   "Specify the project group, can be repeated.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "Specify the project tag, can be repeated.",
+		},
 	},
 }
 
 func addWorkspace(c *cli.Context) error {
-	var renaultPath = share.RenaultAbsolutePath()
-	var exist, err = paths.Exists(renaultPath)
-	if err != nil {
-		return fmt.Errorf("check renault path exists error: %+v", err)
-	}
-	if !exist {
-		fmt.Println("Workspace don't initialize.")
-		return nil
+	var exist, err = checkWorkspace()
+	if err != nil || !exist {
+		return err
 	}
 	var url = c.String("url")
 	var name = c.String("name")
@@ -44,30 +51,43 @@ func addWorkspace(c *cli.Context) error {
 	if name == "" {
 		name = getGitName(url)
 	}
-	dirs, err := os.ReadDir(share.PWD)
-	if err != nil {
-		return fmt.Errorf("readDir error: %+v", err)
+	var project = Project{
+		Name:   name,
+		URL:    url,
+		Groups: c.StringSlice("group"),
+		Tags:   c.StringSlice("tag"),
 	}
-	for _, dir := range dirs {
-		if dir.Name() == name {
-			return fmt.Errorf("project name already exists")
+	if p := c.String("path"); p != "" {
+		if p = path.Clean(p); p != name {
+			project.Path = p
 		}
 	}
-	projects, err := loadProjects()
+	if err = validProjectDir(project.dir()); err != nil {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	exist, err = paths.Exists(project.absPath())
 	if err != nil {
-		return fmt.Errorf("loadProjects error: %+v", err)
+		return fmt.Errorf("check project path exists error: %+v", err)
+	}
+	if exist && !m.ignored(project.dir()) {
+		return fmt.Errorf("project path already exists")
 	}
-	for _, project := range projects {
-		if project.Name == name {
+	for _, p := range m.Projects {
+		if p.Name == name {
 			return fmt.Errorf("project name already exists")
 		}
+		if p.dir() == project.dir() {
+			return fmt.Errorf("project path already exists")
+		}
 	}
-	projects = append(projects, Project{
-		Name: name,
-		URL:  url,
-	})
-	if err = saveProjects(projects); err != nil {
-		return fmt.Errorf("saveProjects error: %+v", err)
+	m.unignore(project.dir())
+	m.Projects = append(m.Projects, project)
+	if err = saveManifest(m); err != nil {
+		return fmt.Errorf("saveManifest error: %+v", err)
 	}
 	return nil
 }
@@ -0,0 +1,569 @@
+package workspace
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/pinealctx/renault/pkg/share"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	bundleVersion      = 1
+	bundleIndexFile    = "bundle.yaml"
+	bundleManifestFile = "manifest.yaml"
+	bundleDir          = "bundles"
+)
+
+var bundleCommand = &cli.Command{
+	Name:  "bundle",
+	Usage: "Transfer the workspace projects offline with git bundles.",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "export",
+			Usage:     "Pack the workspace projects and the manifest into an archive.",
+			ArgsUsage: "<file>",
+			Action:    exportBundle,
+			Flags: append([]cli.Flag{
+				&cli.BoolFlag{
+					Name:  "full",
+					Usage: "Export the whole history instead of the commits since the last export.",
+				},
+			}, selectorFlags()...),
+		},
+		{
+			Name:      "import",
+			Usage:     "Clone or fetch the workspace projects from an archive.",
+			ArgsUsage: "<file>",
+			Action:    importBundle,
+		},
+	},
+}
+
+type BundleIndex struct {
+	Version  int           `yaml:"version"`
+	Created  time.Time     `yaml:"created"`
+	Projects []BundleEntry `yaml:"projects"`
+}
+
+type BundleEntry struct {
+	Name          string            `yaml:"name"`
+	File          string            `yaml:"file,omitempty"`
+	Head          string            `yaml:"head,omitempty"`
+	Refs          map[string]string `yaml:"refs"`
+	Prerequisites []string          `yaml:"prerequisites,omitempty"`
+}
+
+func newBundleIndex() *BundleIndex {
+	return &BundleIndex{Version: bundleVersion}
+}
+
+func (b *BundleIndex) find(name string) *BundleEntry {
+	for i := range b.Projects {
+		if b.Projects[i].Name == name {
+			return &b.Projects[i]
+		}
+	}
+	return nil
+}
+
+func (b *BundleIndex) put(entry BundleEntry) {
+	if e := b.find(entry.Name); e != nil {
+		*e = entry
+		return
+	}
+	b.Projects = append(b.Projects, entry)
+}
+
+func exportBundle(c *cli.Context) error {
+	var file = c.Args().First()
+	if file == "" {
+		return fmt.Errorf("archive file must be specified, eg: renault w bundle export workspace.tar.gz")
+	}
+	var sel, err = newSelector(c)
+	if err != nil {
+		return err
+	}
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	state, err := loadBundleState()
+	if err != nil {
+		return fmt.Errorf("loadBundleState error: %+v", err)
+	}
+	var last = state
+	if c.Bool("full") {
+		last = newBundleIndex()
+	}
+	dir, err := ioutil.TempDir("", "renault-bundle-")
+	if err != nil {
+		return fmt.Errorf("make temp dir error: %+v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	var s = gits.NewSession(c.Context)
+	var index = newBundleIndex()
+	index.Created = time.Now()
+	var exported = newManifest()
+	exported.Defaults = m.Defaults
+	var failed int
+	for _, p := range sel.filter(m.Projects) {
+		entry, err := exportProject(s, &p, last.find(p.Name), dir)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Printf("[%s] export error: %+v\n", p.Name, err)
+			continue
+		case entry == nil:
+			fmt.Printf("[%s] unchanged since the last export.\n", p.Name)
+		case len(entry.Prerequisites) > 0:
+			index.Projects = append(index.Projects, *entry)
+			fmt.Printf("[%s] exported %d ref(s) since the last export.\n", p.Name, len(entry.Refs))
+		default:
+			index.Projects = append(index.Projects, *entry)
+			fmt.Printf("[%s] exported %d ref(s).\n", p.Name, len(entry.Refs))
+		}
+		exported.Projects = append(exported.Projects, p)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d project(s) failed to export", failed)
+	}
+	if err = writeBundleArchive(file, dir, exported, index); err != nil {
+		return fmt.Errorf("write archive error: %+v", err)
+	}
+	for _, entry := range index.Projects {
+		state.put(entry)
+	}
+	state.Created = index.Created
+	if err = saveBundleState(state); err != nil {
+		return fmt.Errorf("saveBundleState error: %+v", err)
+	}
+	fmt.Printf("Workspace exported to %s: %d project(s) bundled.\n", file, len(index.Projects))
+	return nil
+}
+
+func exportProject(s *gits.Session, p *Project, last *BundleEntry, dir string) (*BundleEntry, error) {
+	var exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		return nil, fmt.Errorf("check project path exists error: %+v", err)
+	}
+	if !exist {
+		return nil, fmt.Errorf("project is not cloned")
+	}
+	s.SetDir(p.absPath())
+	refs, err := showRefs(s)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil && reflect.DeepEqual(last.Refs, refs) {
+		return nil, nil
+	}
+	var entry = &BundleEntry{
+		Name: p.Name,
+		File: path.Join(bundleDir, p.Name+".bundle"),
+		Refs: refs,
+	}
+	if output, err := s.Output("symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		entry.Head = strings.TrimSpace(string(output))
+	}
+	var file = filepath.Join(dir, filepath.FromSlash(entry.File))
+	var args = []string{"bundle", "create", file, "--branches", "--tags"}
+	if last != nil {
+		for _, commit := range uniqueValues(last.Refs) {
+			if s.Run("cat-file", "-e", commit+"^{commit}") == nil {
+				entry.Prerequisites = append(entry.Prerequisites, commit)
+				args = append(args, "^"+commit)
+			}
+		}
+	}
+	if err = os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, fmt.Errorf("make bundle dir error: %+v", err)
+	}
+	output, err := s.CombinedOutput(args...)
+	if err != nil {
+		if strings.Contains(string(output), "empty bundle") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("git bundle create error: %+v\n%s", err, output)
+	}
+	return entry, nil
+}
+
+func showRefs(s *gits.Session) (map[string]string, error) {
+	var output, err = s.Output("show-ref", "--heads", "--tags")
+	if err != nil {
+		return nil, fmt.Errorf("project has no branches or tags")
+	}
+	var refs = make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			refs[fields[1]] = fields[0]
+		}
+	}
+	return refs, nil
+}
+
+func uniqueValues(m map[string]string) []string {
+	var seen = make(map[string]struct{})
+	var values []string
+	for _, v := range m {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+func writeBundleArchive(file, dir string, m *Manifest, index *BundleIndex) error {
+	var tmp = file + ".tmp"
+	var f, err = os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+	}()
+	var gz = gzip.NewWriter(f)
+	var tw = tar.NewWriter(gz)
+	for _, item := range []struct {
+		name  string
+		value interface{}
+	}{
+		{bundleIndexFile, index},
+		{bundleManifestFile, m},
+	} {
+		buf, err := yaml.Marshal(item.value)
+		if err != nil {
+			return err
+		}
+		if err = writeTarFile(tw, item.name, int64(len(buf)), bytes.NewReader(buf)); err != nil {
+			return err
+		}
+	}
+	for _, entry := range index.Projects {
+		if err = copyTarFile(tw, entry.File, filepath.Join(dir, filepath.FromSlash(entry.File))); err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func writeTarFile(tw *tar.Writer, name string, size int64, r io.Reader) error {
+	var err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, r)
+	return err
+}
+
+func copyTarFile(tw *tar.Writer, name, file string) error {
+	var f, err = os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return writeTarFile(tw, name, info.Size(), f)
+}
+
+func readBundleArchive(file, dir string) (*BundleIndex, *Manifest, error) {
+	var f, err = os.Open(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tr = tar.NewReader(gz)
+	var index *BundleIndex
+	var m *Manifest
+	var files = make(map[string]struct{})
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		var name = path.Clean(hdr.Name)
+		switch {
+		case name == bundleIndexFile:
+			index = newBundleIndex()
+			err = decodeYAML(tr, index)
+		case name == bundleManifestFile:
+			m = newManifest()
+			err = decodeYAML(tr, m)
+		case strings.HasPrefix(name, bundleDir+"/") && hdr.Typeflag == tar.TypeReg:
+			files[name] = struct{}{}
+			err = extractFile(tr, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read %s error: %+v", name, err)
+		}
+	}
+	if index == nil || m == nil {
+		return nil, nil, fmt.Errorf("%s is not a workspace bundle", file)
+	}
+	if index.Version != bundleVersion {
+		return nil, nil, fmt.Errorf("unsupported bundle version %d", index.Version)
+	}
+	for _, entry := range index.Projects {
+		if _, ok := files[entry.File]; !ok {
+			return nil, nil, fmt.Errorf("bundle of %s is missing", entry.Name)
+		}
+	}
+	return index, m, nil
+}
+
+func decodeYAML(r io.Reader, v interface{}) error {
+	var buf, err = ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(buf, v)
+}
+
+func extractFile(r io.Reader, file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	var f, err = os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func importBundle(c *cli.Context) error {
+	var file = c.Args().First()
+	if file == "" {
+		return fmt.Errorf("archive file must be specified, eg: renault w bundle import workspace.tar.gz")
+	}
+	var dir, err = ioutil.TempDir("", "renault-bundle-")
+	if err != nil {
+		return fmt.Errorf("make temp dir error: %+v", err)
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	index, exported, err := readBundleArchive(file, dir)
+	if err != nil {
+		return fmt.Errorf("read archive error: %+v", err)
+	}
+	m, err := openWorkspace(exported)
+	if err != nil {
+		return err
+	}
+	var changed bool
+	for _, p := range exported.Projects {
+		if err = validProjectDir(p.dir()); err != nil {
+			return fmt.Errorf("project %s: %+v", p.Name, err)
+		}
+		if m.find(p.Name) == nil {
+			m.Projects = append(m.Projects, p)
+			changed = true
+		}
+	}
+	if changed {
+		if err = saveManifest(m); err != nil {
+			return fmt.Errorf("saveManifest error: %+v", err)
+		}
+	}
+	m.bind()
+	var s = gits.NewSession(c.Context)
+	var failed int
+	for _, entry := range index.Projects {
+		var msg, err = importProject(s, m.find(entry.Name), &entry, filepath.Join(dir, filepath.FromSlash(entry.File)))
+		if err != nil {
+			failed++
+			fmt.Printf("[%s] import error: %+v\n", entry.Name, err)
+			continue
+		}
+		fmt.Printf("[%s] %s\n", entry.Name, msg)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d project(s) failed to import", failed)
+	}
+	fmt.Println("Workspace import completed.")
+	return nil
+}
+
+func openWorkspace(exported *Manifest) (*Manifest, error) {
+	var exist, err = paths.Exists(share.RenaultAbsolutePath())
+	if err != nil {
+		return nil, fmt.Errorf("check renault path exists error: %+v", err)
+	}
+	if exist {
+		var m, err = loadManifest()
+		if err != nil {
+			return nil, fmt.Errorf("loadManifest error: %+v", err)
+		}
+		return m, nil
+	}
+	if err = os.Mkdir(share.RenaultAbsolutePath(), 0755); err != nil {
+		return nil, fmt.Errorf("make renault dir error: %+v", err)
+	}
+	fmt.Printf("Initialized workspace %s.\n", share.PWD)
+	var m = newManifest()
+	m.Defaults = exported.Defaults
+	return m, nil
+}
+
+func importProject(s *gits.Session, p *Project, entry *BundleEntry, bundle string) (string, error) {
+	if p == nil {
+		return "", fmt.Errorf("project is not in the manifest")
+	}
+	var exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		return "", fmt.Errorf("check project path exists error: %+v", err)
+	}
+	if !exist {
+		if len(entry.Prerequisites) > 0 {
+			return "", fmt.Errorf("project is not cloned and the bundle is incremental, import a full bundle first")
+		}
+		return cloneBundle(s, p, entry, bundle)
+	}
+	s.SetDir(p.absPath())
+	output, err := s.CombinedOutput("fetch", bundle, "+refs/heads/*:refs/remotes/"+p.remote()+"/*", "+refs/tags/*:refs/tags/*")
+	if err != nil {
+		return "", fmt.Errorf("git fetch bundle error: %+v\n%s", err, output)
+	}
+	status, err := statusProject(s, p, false)
+	if err != nil {
+		return "", fmt.Errorf("status project error: %+v", err)
+	}
+	if !status.PullDecision(gits.PullPolicy{}).Allowed {
+		return "fetched: " + status.Fmt(), nil
+	}
+	output, err = s.CombinedOutput("merge", "--ff-only", "@{upstream}")
+	if err != nil {
+		return "", fmt.Errorf("git merge --ff-only error: %+v\n%s", err, output)
+	}
+	status, err = statusProject(s, p, false)
+	if err != nil {
+		return "", fmt.Errorf("status project again error: %+v", err)
+	}
+	status.SetNewPull()
+	return "fetched and fast-forwarded: " + status.Fmt(), nil
+}
+
+func cloneBundle(s *gits.Session, p *Project, entry *BundleEntry, bundle string) (string, error) {
+	var pp = p.absPath()
+	var parent, base = filepath.Dir(pp), filepath.Base(pp)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", fmt.Errorf("mkdir error: %+v", err)
+	}
+	var tmp, err = ioutil.TempDir(parent, partialClonePrefix(base))
+	if err != nil {
+		return "", fmt.Errorf("temp dir error: %+v", err)
+	}
+	defer func() {
+		if tmp != "" {
+			_ = os.RemoveAll(tmp)
+		}
+	}()
+	var args = []string{"clone", "--origin", p.remote()}
+	var branch = entry.Head
+	if branch == "" {
+		branch = p.branch()
+	}
+	if branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	s.SetDir(parent)
+	output, err := s.CombinedOutput(append(args, bundle, tmp)...)
+	if err != nil {
+		return "", fmt.Errorf("git clone bundle error: %+v\n%s", err, output)
+	}
+	s.SetDir(tmp)
+	output, err = s.CombinedOutput("remote", "set-url", p.remote(), p.URL)
+	if err != nil {
+		return "", fmt.Errorf("git remote set-url error: %+v\n%s", err, output)
+	}
+	if err = os.Rename(tmp, pp); err != nil {
+		return "", fmt.Errorf("rename error: %+v", err)
+	}
+	tmp = ""
+	if err = addRemotes(s, p); err != nil {
+		return "", fmt.Errorf("add remotes error: %+v", err)
+	}
+	status, err := statusProject(s, p, false)
+	if err != nil {
+		return "", fmt.Errorf("status project error: %+v", err)
+	}
+	return "cloned: " + status.Fmt(), nil
+}
+
+func loadBundleState() (*BundleIndex, error) {
+	var state = newBundleIndex()
+	var buff, err = ioutil.ReadFile(share.BundleAbsoluteFile())
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loadBundleState readFile error: %+v", err)
+	}
+	if err = yaml.Unmarshal(buff, state); err != nil {
+		return nil, fmt.Errorf("loadBundleState unmarshal error: %+v", err)
+	}
+	return state, nil
+}
+
+func saveBundleState(state *BundleIndex) error {
+	state.Version = bundleVersion
+	var buf, err = yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("saveBundleState marshal error: %+v", err)
+	}
+	if err = ioutil.WriteFile(share.BundleAbsoluteFile(), buf, 0644); err != nil {
+		return fmt.Errorf("saveBundleState writeFile error: %+v", err)
+	}
+	return nil
+}
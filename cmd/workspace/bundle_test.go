@@ -0,0 +1,100 @@
+package workspace
+
+import (
+	"context"
+	"github.com/pinealctx/renault/pkg/share"
+	"github.com/urfave/cli/v2"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_BundleArchive(t *testing.T) {
+	var src, dst = t.TempDir(), t.TempDir()
+	var entry = BundleEntry{Name: "user", File: "bundles/user.bundle", Head: "master", Refs: map[string]string{"refs/heads/master": "abc"}}
+	if err := os.MkdirAll(filepath.Join(src, "bundles"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "bundles", "user.bundle"), []byte("bundle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var m = newManifest()
+	m.Projects = []Project{{Name: "user", URL: "git@gl.codectn.com:hermes/user.git"}}
+	var index = newBundleIndex()
+	index.Projects = []BundleEntry{entry}
+	var file = filepath.Join(t.TempDir(), "workspace.tar.gz")
+	if err := writeBundleArchive(file, src, m, index); err != nil {
+		t.Fatal(err)
+	}
+	gotIndex, gotManifest, err := readBundleArchive(file, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotManifest.find("user") == nil || gotIndex.find("user").Head != "master" {
+		t.Errorf("unexpected archive content: %+v %+v", gotManifest, gotIndex)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(dst, "bundles", "user.bundle"))
+	if err != nil || string(buf) != "bundle" {
+		t.Errorf("unexpected bundle content %q: %v", buf, err)
+	}
+}
+
+func Test_BundleExportImport(t *testing.T) {
+	setupWorkspace(t, "version: 1\nprojects:\n- name: user\n  url: git@gl.codectn.com:hermes/user.git\n")
+	var src, dst = share.PWD, t.TempDir()
+	var archive = filepath.Join(t.TempDir(), "workspace.tar.gz")
+	var app = &cli.App{Commands: []*cli.Command{bundleCommand}}
+	var bundle = func(pwd string, args ...string) {
+		share.PWD = pwd
+		if err := app.RunContext(context.Background(), append([]string{"renault", "bundle"}, args...)); err != nil {
+			t.Fatalf("bundle %v error: %+v", args, err)
+		}
+	}
+	var commit = func(msg string) string {
+		var repo = filepath.Join(src, "user")
+		if err := ioutil.WriteFile(filepath.Join(repo, "README"), []byte(msg), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, repo, "add", "README")
+		runGit(t, repo, "-c", "user.name=renault", "-c", "user.email=renault@test", "commit", "-q", "-m", msg)
+		return runGit(t, repo, "rev-parse", "HEAD")
+	}
+
+	runGit(t, src, "init", "-q", "-b", "master", "user")
+	var first = commit("first")
+	bundle(src, "export", archive)
+	bundle(dst, "import", archive)
+	if got := runGit(t, filepath.Join(dst, "user"), "rev-parse", "HEAD"); got != first {
+		t.Fatalf("imported HEAD %s, want %s", got, first)
+	}
+	if got := runGit(t, filepath.Join(dst, "user"), "remote", "get-url", "origin"); got != "git@gl.codectn.com:hermes/user.git" {
+		t.Errorf("imported origin %s, want the manifest url", got)
+	}
+
+	var second = commit("second")
+	bundle(src, "export", archive)
+	index, _, err := readBundleArchive(archive, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := index.find("user"); entry == nil || len(entry.Prerequisites) != 1 || entry.Prerequisites[0] != first {
+		t.Fatalf("export is not incremental: %+v", index)
+	}
+	bundle(dst, "import", archive)
+	if got := runGit(t, filepath.Join(dst, "user"), "rev-parse", "HEAD"); got != second {
+		t.Fatalf("imported HEAD %s, want %s", got, second)
+	}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	var cmd = exec.Command("git", args...)
+	cmd.Dir = dir
+	var output, err = cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s error: %+v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,189 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/pinealctx/renault/pkg/share"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	cacheDirEnv        = "RENAULT_CACHE_DIR"
+	mirrorLockInterval = 200 * time.Millisecond
+)
+
+type UserConfig struct {
+	CacheDir string `yaml:"cache_dir,omitempty"`
+}
+
+func loadUserConfig() (*UserConfig, error) {
+	var cfg = &UserConfig{}
+	var file, err = share.UserConfigFile()
+	if err != nil {
+		return cfg, nil
+	}
+	buff, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s error: %+v", file, err)
+	}
+	if err = yaml.Unmarshal(buff, cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal %s error: %+v", file, err)
+	}
+	return cfg, nil
+}
+
+func resolveCacheDir() (string, error) {
+	var dir = os.Getenv(cacheDirEnv)
+	if dir == "" {
+		var cfg, err = loadUserConfig()
+		if err != nil {
+			return "", err
+		}
+		dir = cfg.CacheDir
+	}
+	if dir == "" {
+		return "", nil
+	}
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		var home, err = os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expand cache dir error: %+v", err)
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+	return filepath.Abs(dir)
+}
+
+func mirrorPath(cache, rawURL string) string {
+	var host, p = projectHost(rawURL), rawURL
+	switch {
+	case strings.Contains(rawURL, "://"):
+		if u, err := url.Parse(rawURL); err == nil {
+			p = u.Path
+		}
+	case host != "":
+		p = rawURL[strings.Index(rawURL, ":")+1:]
+	}
+	if host == "" {
+		host = "_local"
+	}
+	p = strings.TrimSuffix(path.Clean("/"+p), ".git") + ".git"
+	return filepath.Join(cache, host, filepath.FromSlash(p))
+}
+
+func lockMirror(ctx context.Context, mirror string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(mirror), 0755); err != nil {
+		return nil, err
+	}
+	var file = mirror + ".lock"
+	for {
+		var unlock, err = tryLockFile(file)
+		if err != nil || unlock != nil {
+			return unlock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(mirrorLockInterval):
+		}
+	}
+}
+
+func (t *syncTask) mirror() string {
+	// shallow, filtered or sparse clones would fetch the full history into the mirror
+	if t.options.cacheDir == "" || t.project.partialClone() {
+		return ""
+	}
+	return mirrorPath(t.options.cacheDir, t.project.URL)
+}
+
+func (t *syncTask) usesMirror() bool {
+	var mirror = t.mirror()
+	if mirror == "" {
+		return false
+	}
+	var alternates, err = ioutil.ReadFile(filepath.Join(t.project.absPath(), ".git", "objects", "info", "alternates"))
+	return err == nil && strings.Contains(string(alternates), mirror)
+}
+
+func (t *syncTask) updateMirror() string {
+	var mirror = t.mirror()
+	if mirror == "" {
+		return ""
+	}
+	var unlock, err = lockMirror(t.ctx, mirror)
+	if err != nil {
+		t.warn("Lock cache mirror %s error: %+v", mirror, err)
+		return ""
+	}
+	defer unlock()
+	exist, err := paths.Exists(mirror)
+	if err == nil {
+		if exist {
+			err = t.fetchMirror(mirror)
+		} else {
+			err = t.cloneMirror(mirror)
+		}
+	}
+	if err != nil {
+		t.warn("Update cache mirror %s error: %+v", mirror, err)
+		if !exist {
+			return ""
+		}
+	}
+	return mirror
+}
+
+func (t *syncTask) fetchMirror(mirror string) error {
+	var s = t.session.WithTimeout(t.options.fetchTimeout).SetDir(mirror)
+	var output, err = t.retry("fetch", func() ([]byte, error) {
+		return s.CombinedOutput("fetch", "origin")
+	})
+	if err != nil {
+		return fmt.Errorf("git fetch error: %+v\n%s", err, output)
+	}
+	return nil
+}
+
+func (t *syncTask) cloneMirror(mirror string) error {
+	var parent, base = filepath.Dir(mirror), filepath.Base(mirror)
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+	var tmp, err = ioutil.TempDir(parent, partialClonePrefix(base))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(tmp)
+	}()
+	var s = t.session.WithTimeout(t.options.cloneTimeout).SetDir(parent)
+	output, err := t.retry("clone", func() ([]byte, error) {
+		if err := resetDir(tmp); err != nil {
+			return nil, err
+		}
+		var output, err = s.CombinedOutput("clone", "--mirror", t.project.URL, tmp)
+		return bytes.Replace(output, []byte(tmp), []byte(mirror), -1), err
+	})
+	if err != nil {
+		return fmt.Errorf("git clone --mirror error: %+v\n%s", err, output)
+	}
+	// workspaces borrow objects from the mirror, they must never be pruned
+	output, err = s.SetDir(tmp).CombinedOutput("config", "gc.pruneExpire", "never")
+	if err != nil {
+		return fmt.Errorf("git config error: %+v\n%s", err, output)
+	}
+	return os.Rename(tmp, mirror)
+}
@@ -0,0 +1,19 @@
+package workspace
+
+import (
+	"testing"
+)
+
+func Test_MirrorPath(t *testing.T) {
+	var cases = map[string]string{
+		"git@gl.codectn.com:hermes/user.git":   "/cache/gl.codectn.com/hermes/user.git",
+		"https://github.com/pinealctx/renault": "/cache/github.com/pinealctx/renault.git",
+		"ssh://git@host:22/../../etc/a.git":    "/cache/host/etc/a.git",
+		"/tmp/rem/alpha.git":                   "/cache/_local/tmp/rem/alpha.git",
+	}
+	for u, want := range cases {
+		if got := mirrorPath("/cache", u); got != want {
+			t.Errorf("mirrorPath(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
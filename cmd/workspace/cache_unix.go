@@ -0,0 +1,30 @@
+//go:build !windows
+// +build !windows
+
+package workspace
+
+import (
+	"os"
+	"syscall"
+)
+
+// tryLockFile returns a nil unlock func without error when the file is locked by others.
+func tryLockFile(file string) (func(), error) {
+	var f, err = os.OpenFile(file, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		_ = f.Close()
+		return nil, nil
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
@@ -0,0 +1,20 @@
+package workspace
+
+import (
+	"os"
+)
+
+// tryLockFile returns a nil unlock func without error when the file is locked by others.
+func tryLockFile(file string) (func(), error) {
+	var f, err = os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if os.IsExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = f.Close()
+		_ = os.Remove(file)
+	}, nil
+}
@@ -0,0 +1,125 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var checkoutCommand = &cli.Command{
+	Name:      "checkout",
+	Aliases:   []string{"co"},
+	Usage:     "Switch the workspace all projects to the branch, create it if necessary.",
+	ArgsUsage: "<branch>",
+	Action:    checkoutWorkspace,
+	Flags:     selectorFlags(),
+}
+
+func checkoutWorkspace(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("branch must be specified, eg: renault w checkout feature/login")
+	}
+	var branch = c.Args().First()
+	var sel, err = newSelector(c)
+	if err != nil {
+		return err
+	}
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	timeout, err := m.Defaults.fetchTimeout()
+	if err != nil {
+		return err
+	}
+	var failed int32
+	var mu sync.Mutex
+	err = eachProject(m.Defaults.jobs(), sel.filter(m.Projects), func(p *Project) {
+		var msg, err = checkoutProject(c.Context, p, branch, timeout)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			atomic.AddInt32(&failed, 1)
+			fmt.Printf("[%s] checkout error: %+v\n", p.Name, err)
+			return
+		}
+		fmt.Printf("[%s] %s\n", p.Name, msg)
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d project(s) failed to checkout %s", failed, branch)
+	}
+	return nil
+}
+
+func checkoutProject(ctx context.Context, p *Project, branch string, timeout time.Duration) (string, error) {
+	var exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		return "", fmt.Errorf("check project path exists error: %+v", err)
+	}
+	if !exist {
+		return "", fmt.Errorf("project is not cloned")
+	}
+	var s = newSession(ctx, timeout)
+
+	status, err := statusProject(s, p, true)
+	if err != nil {
+		return "", fmt.Errorf("status project error: %+v", err)
+	}
+	if status.Branch() == branch {
+		return fmt.Sprintf("already on %s: %s", branch, status.Fmt()), nil
+	}
+	if status.IsDirty() {
+		return "", fmt.Errorf("refuse to checkout with a dirty index: %s", status.Fmt())
+	}
+	var remoteBranch = p.remote() + "/" + branch
+	var args []string
+	switch {
+	case gitRefExists(s, "refs/heads/"+branch):
+		args = []string{"checkout", branch}
+	case gitRefExists(s, "refs/remotes/"+remoteBranch):
+		args = []string{"checkout", "-b", branch, "--track", remoteBranch}
+	default:
+		start, err := defaultStartPoint(s, p)
+		if err != nil {
+			return "", err
+		}
+		args = []string{"checkout", "--no-track", "-b", branch, start}
+	}
+	output, err := s.CombinedOutput(args...)
+	if err != nil {
+		return "", fmt.Errorf("git %s error: %+v\n%s", strings.Join(args, " "), err, output)
+	}
+	status, err = statusProject(s, p, false)
+	if err != nil {
+		return "", fmt.Errorf("status project again error: %+v", err)
+	}
+	return fmt.Sprintf("switched to %s: %s", branch, status.Fmt()), nil
+}
+
+func defaultStartPoint(s *gits.Session, p *Project) (string, error) {
+	if branch := p.branch(); branch != "" {
+		return p.remote() + "/" + branch, nil
+	}
+	var output, err = s.Output("symbolic-ref", "--short", "refs/remotes/"+p.remote()+"/HEAD")
+	if err != nil {
+		return "", fmt.Errorf("resolve default branch of %s error: %+v", p.remote(), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func gitRefExists(s *gits.Session, ref string) bool {
+	return s.Run("show-ref", "--verify", "--quiet", ref) == nil
+}
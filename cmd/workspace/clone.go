@@ -0,0 +1,119 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/pinealctx/renault/pkg/regex"
+	"strconv"
+	"strings"
+)
+
+const (
+	commitPattern = `^[0-9a-f]{7,40}$`
+)
+
+func (p *Project) cloneArgs() []string {
+	var args = []string{"clone", "--origin", p.remote()}
+	if branch := p.cloneBranch(); branch != "" {
+		args = append(args, "--branch", branch)
+	}
+	if p.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(p.Depth))
+	}
+	if p.Filter != "" {
+		args = append(args, "--filter="+p.Filter)
+	}
+	if p.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if len(p.Sparse) > 0 {
+		args = append(args, "--sparse")
+	}
+	return args
+}
+
+// cloneBranch is the ref itself for shallow or single branch clones, which only fetch the cloned branch.
+func (p *Project) cloneBranch() string {
+	if p.Ref != "" && (p.Depth > 0 || p.SingleBranch) {
+		return p.Ref
+	}
+	return p.branch()
+}
+
+func (p *Project) cloneDetail() []string {
+	var detail []string
+	if p.Depth > 0 {
+		detail = append(detail, "depth "+strconv.Itoa(p.Depth))
+	}
+	if p.Filter != "" {
+		detail = append(detail, "filter "+p.Filter)
+	}
+	if p.SingleBranch {
+		detail = append(detail, "single branch")
+	}
+	if len(p.Sparse) > 0 {
+		detail = append(detail, "sparse "+strings.Join(p.Sparse, " "))
+	}
+	return detail
+}
+
+func (p *Project) partialClone() bool {
+	return p.Depth > 0 || p.Filter != "" || p.SingleBranch || len(p.Sparse) > 0
+}
+
+func (p *Project) validateClone() error {
+	if p.Depth < 0 {
+		return fmt.Errorf("invalid depth %d", p.Depth)
+	}
+	if (p.Depth > 0 || p.SingleBranch) && regex.Match(commitPattern, p.Ref) {
+		return fmt.Errorf("commit ref %s can not be used with depth or single_branch", p.Ref)
+	}
+	for _, pattern := range p.Sparse {
+		if pattern == "" || strings.HasPrefix(pattern, "!") {
+			return fmt.Errorf("invalid sparse pattern %q, must be a directory", pattern)
+		}
+	}
+	return nil
+}
+
+func (t *syncTask) sparseCheckout(cloned bool) error {
+	var s, p = t.session, t.project
+	if len(p.Sparse) == 0 {
+		return nil
+	}
+	s.SetDir(p.absPath())
+	if !cloned {
+		var output, err = s.Output("sparse-checkout", "list")
+		if err == nil && sameLines(string(output), p.Sparse) {
+			return nil
+		}
+		t.warn("Sparse patterns changed, re-apply: %s.", strings.Join(p.Sparse, " "))
+	}
+	var args = append([]string{"sparse-checkout", "set", "--cone"}, p.Sparse...)
+	var output, err = t.git(t.options.fetchTimeout, args...)
+	if err != nil {
+		return fmt.Errorf("git sparse-checkout set error: %+v\n%s", err, output)
+	}
+	return nil
+}
+
+func sameLines(output string, lines []string) bool {
+	var got = make(map[string]struct{})
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			got[line] = struct{}{}
+		}
+	}
+	var want = make(map[string]struct{})
+	for _, line := range lines {
+		want[strings.Trim(line, "/")] = struct{}{}
+	}
+	if len(got) != len(want) {
+		return false
+	}
+	for line := range want {
+		if _, ok := got[line]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package workspace
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_CloneArgs(t *testing.T) {
+	var p = Project{Name: "mono", Branch: "main", Depth: 1, Filter: "blob:none", SingleBranch: true, Sparse: []string{"api"}}
+	var want = []string{"clone", "--origin", "origin", "--branch", "main", "--depth", "1", "--filter=blob:none", "--single-branch", "--sparse"}
+	if got := p.cloneArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cloneArgs() = %v, want %v", got, want)
+	}
+	p = Project{Name: "mono", Branch: "main", Ref: "v1.0", Depth: 1}
+	want = []string{"clone", "--origin", "origin", "--branch", "v1.0", "--depth", "1"}
+	if got := p.cloneArgs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("cloneArgs() = %v, want %v", got, want)
+	}
+	for ref, valid := range map[string]bool{"v1.0": true, "release": true, "1a2b3c4": false, "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d": false} {
+		p = Project{Name: "mono", Ref: ref, SingleBranch: true}
+		if err := p.validateClone(); (err == nil) != valid {
+			t.Errorf("validateClone() with ref %s = %v, want valid %v", ref, err, valid)
+		}
+	}
+	if !sameLines("api\nweb\n", []string{"web/", "api"}) {
+		t.Errorf("sameLines should ignore order and trailing slash")
+	}
+}
@@ -0,0 +1,68 @@
+package workspace
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	discoverDepth = 3
+)
+
+func discoverProjects(root string, projects []Project, ignore []string) ([]Project, error) {
+	var dirs = make(map[string]struct{}, len(projects)+len(ignore))
+	for _, dir := range ignore {
+		dirs[dir] = struct{}{}
+	}
+	var names = make(map[string]struct{}, len(projects))
+	for _, p := range projects {
+		dirs[p.dir()] = struct{}{}
+		names[p.Name] = struct{}{}
+	}
+	var found []Project
+	var err = filepath.WalkDir(root, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || fp == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, fp)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if _, ok := dirs[rel]; ok {
+			return filepath.SkipDir
+		}
+		var url = getGitURL(fp)
+		if url == "" {
+			if strings.Count(rel, "/")+1 >= discoverDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		var p = Project{
+			Name: getGitName(url),
+			URL:  url,
+		}
+		if _, ok := names[p.Name]; ok {
+			p.Name = rel
+		}
+		if p.Name != rel {
+			p.Path = rel
+		}
+		names[p.Name] = struct{}{}
+		found = append(found, p)
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discoverProjects walk error: %+v", err)
+	}
+	return found, nil
+}
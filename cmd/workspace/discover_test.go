@@ -0,0 +1,48 @@
+package workspace
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeGitDir(t *testing.T, root, rel, url string) {
+	var dir = filepath.Join(root, rel, ".git")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	var config = "[remote \"origin\"]\n\turl = " + url + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_DiscoverProjects(t *testing.T) {
+	var root = t.TempDir()
+	makeGitDir(t, root, "back", "git@gl.codectn.com:hermes/back.git")
+	makeGitDir(t, root, "hermes/user", "git@gl.codectn.com:hermes/user.git")
+	makeGitDir(t, root, "legacy/user", "git@gl.codectn.com:legacy/user.git")
+	makeGitDir(t, root, "a/b/c/deep", "git@gl.codectn.com:a/deep.git")
+	makeGitDir(t, root, "known", "git@gl.codectn.com:hermes/known.git")
+	makeGitDir(t, root, "removed/order", "git@gl.codectn.com:hermes/order.git")
+
+	var found, err = discoverProjects(root, []Project{{Name: "known"}}, []string{"removed/order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want = map[string]string{
+		"back":        "",
+		"user":        "hermes/user",
+		"legacy/user": "",
+	}
+	if len(found) != len(want) {
+		t.Fatalf("unexpected projects: %+v", found)
+	}
+	for _, p := range found {
+		var path, ok = want[p.Name]
+		if !ok || p.Path != path {
+			t.Fatalf("unexpected project: %+v", p)
+		}
+	}
+}
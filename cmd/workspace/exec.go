@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/pinealctx/renault/pkg/procs"
+	"github.com/urfave/cli/v2"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var execCommand = &cli.Command{
+	Name:      "exec",
+	Aliases:   []string{"e"},
+	Usage:     "Execute a command in the workspace all projects.",
+	ArgsUsage: "-- <command> [args...]",
+	Action:    execWorkspace,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "serial",
+			Usage: "Execute the command in one project after another.",
+		},
+		&cli.BoolFlag{
+			Name:  "fail-fast",
+			Usage: "Stop executing in the remaining projects after the first failure.",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "Specify the timeout of the command in each project, eg: 30s.",
+		},
+	}, selectorFlags()...),
+}
+
+func execWorkspace(c *cli.Context) error {
+	var args = c.Args().Slice()
+	if len(args) == 0 {
+		return fmt.Errorf("command must be specified, eg: renault w exec -- git status")
+	}
+	var sel, err = newSelector(c)
+	if err != nil {
+		return err
+	}
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	var size = m.Defaults.jobs()
+	if c.Bool("serial") {
+		size = 1
+	}
+	var failFast = c.Bool("fail-fast")
+	var timeout = c.Duration("timeout")
+	// fail fast stops starting new projects, the commands already running are left to finish
+	var stopped int32
+	var failed int32
+	var mu sync.Mutex
+	var printf = func(format string, a ...interface{}) {
+		mu.Lock()
+		fmt.Printf(format+"\n", a...)
+		mu.Unlock()
+	}
+	err = eachProject(size, sel.filter(m.Projects), func(p *Project) {
+		if atomic.LoadInt32(&stopped) != 0 {
+			printf("[%s] skipped after failure.", p.Name)
+			return
+		}
+		var exist, err = paths.Exists(p.absPath())
+		switch {
+		case err != nil:
+			err = fmt.Errorf("check project path exists error: %+v", err)
+		case !exist:
+			printf("[%s] skipped, not cloned.", p.Name)
+			return
+		default:
+			err = execProject(c.Context, p, args, timeout, &mu)
+		}
+		if err != nil {
+			printf("[%s] exec error: %+v", p.Name, err)
+			atomic.AddInt32(&failed, 1)
+			if failFast {
+				atomic.StoreInt32(&stopped, 1)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("Command failed in %d project(s).", failed), 1)
+	}
+	return nil
+}
+
+func execProject(ctx context.Context, p *Project, args []string, timeout time.Duration, mu *sync.Mutex) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var stdout = newPrefixWriter(os.Stdout, p.Name, mu)
+	var stderr = newPrefixWriter(os.Stderr, p.Name, mu)
+	defer stdout.Flush()
+	defer stderr.Flush()
+	var cmd = exec.Command(args[0], args[1:]...)
+	cmd.Dir = p.absPath()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := procs.Run(ctx, cmd); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timeout after %s", timeout)
+		}
+		return err
+	}
+	return nil
+}
+
+type prefixWriter struct {
+	w      io.Writer
+	prefix []byte
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, name string, mu *sync.Mutex) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte("[" + name + "] "), mu: mu}
+}
+
+func (pw *prefixWriter) Write(b []byte) (int, error) {
+	pw.buf = append(pw.buf, b...)
+	for {
+		var i = bytes.IndexByte(pw.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		if err := pw.writeLine(pw.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		pw.buf = pw.buf[i+1:]
+	}
+}
+
+func (pw *prefixWriter) Flush() {
+	if len(pw.buf) == 0 {
+		return
+	}
+	_ = pw.writeLine(append(pw.buf, '\n'))
+	pw.buf = nil
+}
+
+func (pw *prefixWriter) writeLine(line []byte) error {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if _, err := pw.w.Write(pw.prefix); err != nil {
+		return err
+	}
+	var _, err = pw.w.Write(line)
+	return err
+}
@@ -0,0 +1,43 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"github.com/pinealctx/renault/pkg/share"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_PrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	var pw = newPrefixWriter(&buf, "user", &sync.Mutex{})
+	for _, s := range []string{"hel", "lo\nwor", "ld\n", "tail"} {
+		if _, err := pw.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pw.Flush()
+	var want = "[user] hello\n[user] world\n[user] tail\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func Test_ExecProjectTimeout(t *testing.T) {
+	share.PWD = t.TempDir()
+	var p = &Project{Name: "user"}
+	if err := os.Mkdir(p.absPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var start = time.Now()
+	var err = execProject(context.Background(), p, []string{"sh", "-c", "sleep 5; echo done"}, 100*time.Millisecond, &sync.Mutex{})
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("want timeout error, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("timeout returned after %s, the forked child was not killed", d)
+	}
+}
@@ -0,0 +1,25 @@
+package workspace
+
+import (
+	"net/url"
+	"strings"
+)
+
+func projectHost(rawURL string) string {
+	if strings.Contains(rawURL, "://") {
+		var u, err = url.Parse(rawURL)
+		if err != nil || u.Scheme == "file" {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+	var i = strings.Index(rawURL, ":")
+	if i <= 0 || strings.Contains(rawURL[:i], "/") {
+		return ""
+	}
+	var host = rawURL[:i]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return strings.ToLower(host)
+}
@@ -0,0 +1,21 @@
+package workspace
+
+import (
+	"testing"
+)
+
+func Test_ProjectHost(t *testing.T) {
+	var cases = map[string]string{
+		"git@gl.codectn.com:hermes/user.git":      "gl.codectn.com",
+		"ssh://git@GitHub.com:22/pinealctx/a.git": "github.com",
+		"https://github.com/pinealctx/renault":    "github.com",
+		"file:///tmp/rem/alpha.git":               "",
+		"/tmp/rem/alpha.git":                      "",
+		"../rem/alpha.git":                        "",
+	}
+	for u, want := range cases {
+		if got := projectHost(u); got != want {
+			t.Errorf("projectHost(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
@@ -23,35 +23,30 @@ var initCommand = &cli.Command{
 	Action:  initWorkspace,
 }
 
-func initWorkspace(*cli.Context) error {
+func initWorkspace(c *cli.Context) error {
+	// a workspace may be nested in another one, so init ignores the ancestors unless the workspace is specified
+	if c.String("workspace") == "" {
+		var pwd, err = os.Getwd()
+		if err != nil {
+			return fmt.Errorf("getwd error: %+v", err)
+		}
+		share.PWD = pwd
+	}
 	var renaultPath = share.RenaultAbsolutePath()
 	var exist, err = paths.Exists(renaultPath)
 	if err != nil {
 		return fmt.Errorf("check renault path exists error: %+v", err)
 	}
 	if exist {
-		fmt.Println("Workspace already exists.")
+		fmt.Printf("Workspace already exists: %s\n", share.PWD)
 		return nil
 	}
 	if err = os.Mkdir(renaultPath, 0755); err != nil {
 		return fmt.Errorf("make renault dir error: %+v", err)
 	}
-	dirs, err := os.ReadDir(share.PWD)
+	projects, err := discoverProjects(share.PWD, nil, nil)
 	if err != nil {
-		return fmt.Errorf("readDir error: %+v", err)
-	}
-	var projects = make([]Project, 0, len(dirs))
-	for _, dir := range dirs {
-		if strings.HasPrefix(dir.Name(), ".") {
-			continue
-		}
-		var url = getGitURL(share.ProjectAbsolutePath(dir.Name()))
-		if url != "" {
-			projects = append(projects, Project{
-				Name: getGitName(url),
-				URL:  url,
-			})
-		}
+		return fmt.Errorf("discoverProjects error: %+v", err)
 	}
 	if err = saveProjects(projects); err != nil {
 		return fmt.Errorf("saveProjects error: %+v", err)
@@ -60,11 +55,6 @@ func initWorkspace(*cli.Context) error {
 	return nil
 }
 
-type Project struct {
-	Name string `yaml:"name"`
-	URL  string `yaml:"url"`
-}
-
 func getGitURL(dir string) string {
 	gitConfigPath := path.Join(dir, GitConfigPath)
 	exist, err := paths.Exists(gitConfigPath)
@@ -0,0 +1,106 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+)
+
+var listCommand = &cli.Command{
+	Name:    "list",
+	Aliases: []string{"ls"},
+	Usage:   "List the workspace all projects.",
+	Action:  listWorkspace,
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Specify the output format: table, json or yaml.",
+			Value:   "table",
+		},
+	}, selectorFlags()...),
+}
+
+type listItem struct {
+	Name   string   `json:"name" yaml:"name"`
+	URL    string   `json:"url" yaml:"url"`
+	Path   string   `json:"path" yaml:"path"`
+	Exists bool     `json:"exists" yaml:"exists"`
+	Branch string   `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Tags   []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+func listWorkspace(c *cli.Context) error {
+	var output = c.String("output")
+	switch output {
+	case "table", "json", "yaml":
+	default:
+		return fmt.Errorf("unknown output format %q", output)
+	}
+	var sel, err = newSelector(c)
+	if err != nil {
+		return err
+	}
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	var items = make([]listItem, 0, len(m.Projects))
+	for _, p := range sel.filter(m.Projects) {
+		var item = listItem{
+			Name:   p.Name,
+			URL:    p.URL,
+			Path:   p.absPath(),
+			Groups: p.Groups,
+			Tags:   p.Tags,
+		}
+		if item.Exists, err = paths.Exists(item.Path); err != nil {
+			return fmt.Errorf("check project path exists error: %+v", err)
+		}
+		if item.Exists {
+			item.Branch = currentBranch(item.Path)
+		}
+		items = append(items, item)
+	}
+	switch output {
+	case "json":
+		var enc = json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(items)
+	case "yaml":
+		var buf, err = yaml.Marshal(items)
+		if err != nil {
+			return fmt.Errorf("marshal yaml error: %+v", err)
+		}
+		_, err = os.Stdout.Write(buf)
+		return err
+	}
+	var w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tBRANCH\tGROUPS\tEXISTS\tPATH\tURL")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%s\n", item.Name, orDash(item.Branch),
+			orDash(strings.Join(item.Groups, ",")), item.Exists, item.Path, item.URL)
+	}
+	return w.Flush()
+}
+
+func currentBranch(dir string) string {
+	var cmd = exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	var out, err = cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
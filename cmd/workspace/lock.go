@@ -0,0 +1,241 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/pinealctx/renault/pkg/share"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	lockVersion = 1
+)
+
+var lockCommand = &cli.Command{
+	Name:   "lock",
+	Usage:  "Record the current commit of the workspace all projects into .renault/lock.yaml.",
+	Action: lockWorkspace,
+	Flags:  selectorFlags(),
+}
+
+type Lock struct {
+	Version  int         `yaml:"version"`
+	Projects []LockEntry `yaml:"projects"`
+}
+
+type LockEntry struct {
+	Name   string `yaml:"name"`
+	Commit string `yaml:"commit"`
+	Branch string `yaml:"branch,omitempty"`
+	Tag    string `yaml:"tag,omitempty"`
+}
+
+func (l *Lock) find(name string) *LockEntry {
+	for i := range l.Projects {
+		if l.Projects[i].Name == name {
+			return &l.Projects[i]
+		}
+	}
+	return nil
+}
+
+func (l *Lock) put(entry LockEntry) {
+	if e := l.find(entry.Name); e != nil {
+		*e = entry
+		return
+	}
+	l.Projects = append(l.Projects, entry)
+}
+
+func lockWorkspace(c *cli.Context) error {
+	var sel, err = newSelector(c)
+	if err != nil {
+		return err
+	}
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	timeout, err := m.Defaults.fetchTimeout()
+	if err != nil {
+		return err
+	}
+	lock, err := loadLock()
+	if err != nil {
+		return fmt.Errorf("loadLock error: %+v", err)
+	}
+	var mu sync.Mutex
+	var failed int
+	err = eachProject(m.Defaults.jobs(), sel.filter(m.Projects), func(p *Project) {
+		var entry, err = lockProject(c.Context, p, timeout)
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed++
+			fmt.Printf("[%s] lock error: %+v\n", p.Name, err)
+			return
+		}
+		lock.put(*entry)
+		fmt.Printf("[%s] locked at %s\n", p.Name, shortCommit(entry.Commit))
+	})
+	if err != nil {
+		return err
+	}
+	if err = saveLock(lock); err != nil {
+		return fmt.Errorf("saveLock error: %+v", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d project(s) failed to lock", failed)
+	}
+	fmt.Println("Workspace lock completed.")
+	return nil
+}
+
+func lockProject(ctx context.Context, p *Project, timeout time.Duration) (*LockEntry, error) {
+	var exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		return nil, fmt.Errorf("check project path exists error: %+v", err)
+	}
+	if !exist {
+		return nil, fmt.Errorf("project is not cloned")
+	}
+	var s = newSession(ctx, timeout)
+
+	status, err := statusProject(s, p, false)
+	if err != nil {
+		return nil, fmt.Errorf("status project error: %+v", err)
+	}
+	if status.Commit() == "" || status.Commit() == "(initial)" {
+		return nil, fmt.Errorf("project has no commit")
+	}
+	var entry = &LockEntry{
+		Name:   p.Name,
+		Commit: status.Commit(),
+		Tag:    status.Tag(),
+	}
+	if status.Branch() != "(detached)" {
+		entry.Branch = status.Branch()
+	}
+	return entry, nil
+}
+
+func syncLockedProject(t *syncTask, entry *LockEntry) {
+	var s, p, r = t.session, t.project, t.result
+	if entry == nil {
+		t.fail(fmt.Errorf("project is not locked"))
+		return
+	}
+	var cloned, err = cloneProject(t)
+	if err != nil {
+		t.fail(fmt.Errorf("clone project error: %+v", err))
+		return
+	}
+	if !cloned {
+		var start = time.Now()
+		if err = t.fetch(); err != nil {
+			t.fail(fmt.Errorf("fetch project error: %+v", err))
+			return
+		}
+		t.emit(&syncEvent{Type: eventFetch, DurationMS: sinceMS(start)})
+	}
+	status, err := statusProject(s, p, false)
+	if err != nil {
+		t.fail(fmt.Errorf("status project error: %+v", err))
+		return
+	}
+	r.detail = shortCommit(entry.Commit)
+	if status.Commit() == entry.Commit {
+		if !cloned {
+			t.emitStatus(status)
+		}
+		return
+	}
+	if !cloned {
+		t.warn("drift: HEAD %s, locked %s", shortCommit(status.Commit()), shortCommit(entry.Commit))
+	}
+	if status.IsDirty() || status.Modified() > 0 {
+		t.warn("refuse to checkout locked commit with local changes.")
+		t.emitStatus(status)
+		r.skip("drift %s -> %s, local changes", shortCommit(status.Commit()), shortCommit(entry.Commit))
+		return
+	}
+	var start = time.Now()
+	output, err := s.CombinedOutput("checkout", "--detach", entry.Commit)
+	if err != nil {
+		t.fail(fmt.Errorf("git checkout %s error: %+v\n%s", shortCommit(entry.Commit), err, output))
+		return
+	}
+	if err = t.updateSubmodules(status); err != nil {
+		t.fail(err)
+		return
+	}
+	var oldCommit = status.Commit()
+	status, err = statusProject(s, p, false)
+	if err != nil {
+		t.fail(fmt.Errorf("status project again error: %+v", err))
+		return
+	}
+	t.emit(&syncEvent{
+		Type:       eventCheckout,
+		DurationMS: sinceMS(start),
+		Message:    fmt.Sprintf("checked out locked commit %s.", shortCommit(entry.Commit)),
+		OldCommit:  oldCommit,
+		NewCommit:  status.Commit(),
+	})
+	t.emitStatus(status)
+	r.action = actionCheckedOut
+}
+
+func loadLock() (*Lock, error) {
+	var lock = &Lock{Version: lockVersion}
+	var exist, err = paths.Exists(share.LockAbsoluteFile())
+	if err != nil {
+		return nil, fmt.Errorf("check lock file exists error: %+v", err)
+	}
+	if !exist {
+		return lock, nil
+	}
+	buff, err := ioutil.ReadFile(share.LockAbsoluteFile())
+	if err != nil {
+		return nil, fmt.Errorf("loadLock readFile error: %+v", err)
+	}
+	if err = yaml.Unmarshal(buff, lock); err != nil {
+		return nil, fmt.Errorf("loadLock unmarshal error: %+v", err)
+	}
+	if lock.Version > lockVersion {
+		return nil, fmt.Errorf("unsupported lock version %d, upgrade renault", lock.Version)
+	}
+	return lock, nil
+}
+
+func saveLock(lock *Lock) error {
+	lock.Version = lockVersion
+	sort.Slice(lock.Projects, func(i, j int) bool {
+		return lock.Projects[i].Name < lock.Projects[j].Name
+	})
+	var buf, err = yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("saveLock marshal error: %+v", err)
+	}
+	if err = ioutil.WriteFile(share.LockAbsoluteFile(), buf, 0755); err != nil {
+		return fmt.Errorf("saveLock writeFile error: %+v", err)
+	}
+	return nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
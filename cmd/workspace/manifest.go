@@ -0,0 +1,234 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/pinealctx/renault/pkg/share"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	manifestVersion = 1
+	defaultRemote   = "origin"
+)
+
+type Manifest struct {
+	Version  int       `yaml:"version"`
+	Defaults Defaults  `yaml:"defaults,omitempty"`
+	Projects []Project `yaml:"projects"`
+	Ignore   []string  `yaml:"ignore,omitempty"`
+}
+
+type Defaults struct {
+	Branch       string         `yaml:"branch,omitempty"`
+	Remote       string         `yaml:"remote,omitempty"`
+	PullStrategy string         `yaml:"pull_strategy,omitempty"`
+	Autostash    *bool          `yaml:"autostash,omitempty"`
+	Jobs         int            `yaml:"jobs,omitempty"`
+	CloneTimeout string         `yaml:"clone_timeout,omitempty"`
+	FetchTimeout string         `yaml:"fetch_timeout,omitempty"`
+	Retries      *int           `yaml:"retries,omitempty"`
+	RetryBackoff string         `yaml:"retry_backoff,omitempty"`
+	HostJobs     int            `yaml:"host_jobs,omitempty"`
+	Hosts        map[string]int `yaml:"hosts,omitempty"`
+	Submodules   string         `yaml:"submodules,omitempty"`
+}
+
+type Project struct {
+	Name         string   `yaml:"name"`
+	URL          string   `yaml:"url"`
+	Path         string   `yaml:"path,omitempty"`
+	Branch       string   `yaml:"branch,omitempty"`
+	Ref          string   `yaml:"ref,omitempty"`
+	Remotes      []Remote `yaml:"remotes,omitempty"`
+	Groups       []string `yaml:"groups,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+	PullStrategy string   `yaml:"pull_strategy,omitempty"`
+	Autostash    *bool    `yaml:"autostash,omitempty"`
+	Depth        int      `yaml:"depth,omitempty"`
+	Filter       string   `yaml:"filter,omitempty"`
+	SingleBranch bool     `yaml:"single_branch,omitempty"`
+	Sparse       []string `yaml:"sparse,omitempty"`
+	Submodules   string   `yaml:"submodules,omitempty"`
+
+	defaults *Defaults
+}
+
+type Remote struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+func newManifest() *Manifest {
+	return &Manifest{Version: manifestVersion}
+}
+
+func (m *Manifest) find(name string) *Project {
+	for i := range m.Projects {
+		if m.Projects[i].Name == name {
+			return &m.Projects[i]
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) ignored(dir string) bool {
+	for _, d := range m.Ignore {
+		if d == dir {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manifest) unignore(dir string) {
+	var ignore = m.Ignore[:0]
+	for _, d := range m.Ignore {
+		if d != dir {
+			ignore = append(ignore, d)
+		}
+	}
+	m.Ignore = ignore
+}
+
+func (p *Project) dir() string {
+	if p.Path != "" {
+		return p.Path
+	}
+	return p.Name
+}
+
+func validProjectDir(dir string) error {
+	var clean = path.Clean(dir)
+	if dir == "" || clean == "." || clean == share.RenaultPath || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("project path %s must be inside the workspace", dir)
+	}
+	return nil
+}
+
+func (p *Project) absPath() string {
+	return share.ProjectAbsolutePath(p.dir())
+}
+
+func (p *Project) branch() string {
+	if p.Branch != "" {
+		return p.Branch
+	}
+	if p.defaults != nil {
+		return p.defaults.Branch
+	}
+	return ""
+}
+
+func (p *Project) remote() string {
+	if p.defaults != nil && p.defaults.Remote != "" {
+		return p.defaults.Remote
+	}
+	return defaultRemote
+}
+
+func (m *Manifest) bind() {
+	for i := range m.Projects {
+		m.Projects[i].defaults = &m.Defaults
+	}
+}
+
+func checkWorkspace() (bool, error) {
+	var exist, err = paths.Exists(share.RenaultAbsolutePath())
+	if err != nil {
+		return false, fmt.Errorf("check renault path exists error: %+v", err)
+	}
+	if !exist {
+		return false, cli.Exit("Workspace don't initialize.", 1)
+	}
+	return true, nil
+}
+
+func loadManifest() (*Manifest, error) {
+	var m, legacy, err = readManifest()
+	if err != nil {
+		return nil, err
+	}
+	if legacy {
+		if err = saveManifest(m); err != nil {
+			return nil, fmt.Errorf("loadManifest migrate error: %+v", err)
+		}
+		fmt.Fprintf(os.Stderr, "Migrated %s to manifest version %d.\n", share.ConfigAbsoluteFile(), manifestVersion)
+	}
+	return m, nil
+}
+
+func readManifest() (*Manifest, bool, error) {
+	var buff, err = ioutil.ReadFile(share.ConfigAbsoluteFile())
+	if err != nil {
+		return nil, false, fmt.Errorf("readManifest readFile error: %+v", err)
+	}
+	var raw interface{}
+	if err = yaml.Unmarshal(buff, &raw); err != nil {
+		return nil, false, fmt.Errorf("readManifest unmarshal error: %+v", err)
+	}
+	var m = newManifest()
+	var legacy bool
+	switch raw.(type) {
+	case nil:
+	case []interface{}:
+		if err = yaml.Unmarshal(buff, &m.Projects); err != nil {
+			return nil, false, fmt.Errorf("readManifest unmarshal legacy error: %+v", err)
+		}
+		legacy = true
+	default:
+		if err = yaml.Unmarshal(buff, m); err != nil {
+			return nil, false, fmt.Errorf("readManifest unmarshal error: %+v", err)
+		}
+		if m.Version > manifestVersion {
+			return nil, false, fmt.Errorf("unsupported manifest version %d, upgrade renault", m.Version)
+		}
+		m.Version = manifestVersion
+	}
+	m.bind()
+	return m, legacy, nil
+}
+
+func saveManifest(m *Manifest) error {
+	m.Version = manifestVersion
+	var buf, err = yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("saveManifest marshal error: %+v", err)
+	}
+	if err = ioutil.WriteFile(share.ConfigAbsoluteFile(), buf, 0755); err != nil {
+		return fmt.Errorf("saveManifest writeFile error: %+v", err)
+	}
+	m.bind()
+	return nil
+}
+
+func loadProjects() ([]Project, error) {
+	var m, err = loadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("loadProjects error: %+v", err)
+	}
+	return m.Projects, nil
+}
+
+func saveProjects(projects []Project) error {
+	var exist, err = paths.Exists(share.ConfigAbsoluteFile())
+	if err != nil {
+		return fmt.Errorf("check config file exists error: %+v", err)
+	}
+	var m = newManifest()
+	if exist {
+		if m, err = loadManifest(); err != nil {
+			return fmt.Errorf("saveProjects error: %+v", err)
+		}
+	}
+	m.Projects = projects
+	if err = saveManifest(m); err != nil {
+		return fmt.Errorf("saveProjects error: %+v", err)
+	}
+	return nil
+}
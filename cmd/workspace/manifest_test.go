@@ -0,0 +1,100 @@
+package workspace
+
+import (
+	"github.com/pinealctx/renault/pkg/share"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func setupWorkspace(t *testing.T, config string) {
+	share.PWD = t.TempDir()
+	if err := os.Mkdir(share.RenaultAbsolutePath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(share.ConfigAbsoluteFile(), []byte(config), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_LoadManifestLegacy(t *testing.T) {
+	setupWorkspace(t, `- name: user
+  url: git@gl.codectn.com:hermes/user.git
+- name: back
+  url: git@gl.codectn.com:hermes/back.git
+`)
+	var m, err = loadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != manifestVersion || len(m.Projects) != 2 || m.Projects[1].Name != "back" {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	buf, err := ioutil.ReadFile(share.ConfigAbsoluteFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(buf), "version: 1\n") {
+		t.Fatalf("legacy manifest not migrated: %s", buf)
+	}
+}
+
+func Test_LoadManifestDefaults(t *testing.T) {
+	setupWorkspace(t, `version: 1
+defaults:
+  branch: develop
+  remote: upstream
+projects:
+- name: user
+  url: git@gl.codectn.com:hermes/user.git
+- name: back
+  url: git@gl.codectn.com:hermes/back.git
+  branch: main
+`)
+	var projects, err = loadProjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projects[0].branch() != "develop" || projects[1].branch() != "main" {
+		t.Fatalf("unexpected branches: %s %s", projects[0].branch(), projects[1].branch())
+	}
+	if projects[0].remote() != "upstream" {
+		t.Fatalf("unexpected remote: %s", projects[0].remote())
+	}
+	if err = saveProjects(projects[:1]); err != nil {
+		t.Fatal(err)
+	}
+	m, err := loadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Defaults.Branch != "develop" || len(m.Projects) != 1 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+}
+
+func Test_LoadManifestUnsupported(t *testing.T) {
+	setupWorkspace(t, "version: 99\nprojects: []\n")
+	if _, err := loadManifest(); err == nil {
+		t.Fatal("expected unsupported version error")
+	}
+}
+
+func Test_ValidProjectDir(t *testing.T) {
+	for dir, valid := range map[string]bool{
+		"user":         true,
+		"libs/user":    true,
+		"":             false,
+		".":            false,
+		".renault":     false,
+		"..":           false,
+		"../user":      false,
+		"libs/../../x": false,
+		"/tmp/user":    false,
+	} {
+		if err := validProjectDir(dir); (err == nil) != valid {
+			t.Errorf("validProjectDir(%q) = %v, want valid %v", dir, err, valid)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCloneTimeout = 10 * time.Minute
+	defaultFetchTimeout = 2 * time.Minute
+	defaultRetries      = 2
+	defaultRetryBackoff = time.Second
+	maxRetryBackoff     = 30 * time.Second
+)
+
+type syncOptions struct {
+	jobs         int
+	cloneTimeout time.Duration
+	fetchTimeout time.Duration
+	retries      int
+	retryBackoff time.Duration
+	hostJobs     int
+	hosts        map[string]int
+	cacheDir     string
+}
+
+func syncOptionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:    "jobs",
+			Aliases: []string{"j"},
+			Usage:   fmt.Sprintf("Specify the number of projects synced in parallel. (default: %d)", poolSize),
+		},
+		&cli.IntFlag{
+			Name:  "host-jobs",
+			Usage: "Specify the number of projects synced in parallel from one git host, 0 means no limit.",
+		},
+		&cli.DurationFlag{
+			Name:  "clone-timeout",
+			Usage: fmt.Sprintf("Specify the timeout of git clone. (default: %s)", defaultCloneTimeout),
+		},
+		&cli.DurationFlag{
+			Name:  "fetch-timeout",
+			Usage: fmt.Sprintf("Specify the timeout of git fetch and pull. (default: %s)", defaultFetchTimeout),
+		},
+		&cli.IntFlag{
+			Name:  "retries",
+			Usage: fmt.Sprintf("Specify how many times a git operation is retried after a transient failure. (default: %d)", defaultRetries),
+		},
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Do not use or update the shared object cache.",
+		},
+		&cli.DurationFlag{
+			Name:  "retry-backoff",
+			Usage: fmt.Sprintf("Specify the delay before the first retry, doubled on each retry. (default: %s)", defaultRetryBackoff),
+		},
+	}
+}
+
+func (d *Defaults) jobs() int {
+	if d.Jobs > 0 {
+		return d.Jobs
+	}
+	return poolSize
+}
+
+func (d *Defaults) fetchTimeout() (time.Duration, error) {
+	if d.FetchTimeout == "" {
+		return defaultFetchTimeout, nil
+	}
+	var v, err = time.ParseDuration(d.FetchTimeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid fetch_timeout %q in defaults: %+v", d.FetchTimeout, err)
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("fetch_timeout must be positive")
+	}
+	return v, nil
+}
+
+func newSyncOptions(c *cli.Context, d *Defaults) (*syncOptions, error) {
+	var o = &syncOptions{
+		jobs:         d.jobs(),
+		cloneTimeout: defaultCloneTimeout,
+		retries:      defaultRetries,
+		retryBackoff: defaultRetryBackoff,
+		hostJobs:     d.HostJobs,
+		hosts:        make(map[string]int, len(d.Hosts)),
+	}
+	if d.Jobs < 0 {
+		return nil, fmt.Errorf("invalid jobs %d in defaults", d.Jobs)
+	}
+	var err error
+	if o.fetchTimeout, err = d.fetchTimeout(); err != nil {
+		return nil, err
+	}
+	for host, jobs := range d.Hosts {
+		if jobs < 0 {
+			return nil, fmt.Errorf("invalid jobs %d for host %s in defaults", jobs, host)
+		}
+		o.hosts[strings.ToLower(host)] = jobs
+	}
+	for _, item := range []struct {
+		name  string
+		value string
+		out   *time.Duration
+	}{
+		{"clone_timeout", d.CloneTimeout, &o.cloneTimeout},
+		{"retry_backoff", d.RetryBackoff, &o.retryBackoff},
+	} {
+		if item.value == "" {
+			continue
+		}
+		var v, err = time.ParseDuration(item.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q in defaults: %+v", item.name, item.value, err)
+		}
+		*item.out = v
+	}
+	if d.Retries != nil {
+		o.retries = *d.Retries
+	}
+	if c.IsSet("jobs") {
+		o.jobs = c.Int("jobs")
+	}
+	if c.IsSet("clone-timeout") {
+		o.cloneTimeout = c.Duration("clone-timeout")
+	}
+	if c.IsSet("fetch-timeout") {
+		o.fetchTimeout = c.Duration("fetch-timeout")
+	}
+	if c.IsSet("retries") {
+		o.retries = c.Int("retries")
+	}
+	if c.IsSet("host-jobs") {
+		o.hostJobs = c.Int("host-jobs")
+	}
+	if c.IsSet("retry-backoff") {
+		o.retryBackoff = c.Duration("retry-backoff")
+	}
+	if !c.Bool("no-cache") {
+		if o.cacheDir, err = resolveCacheDir(); err != nil {
+			return nil, fmt.Errorf("resolve cache dir error: %+v", err)
+		}
+	}
+	switch {
+	case o.jobs < 1:
+		return nil, fmt.Errorf("jobs must be at least 1")
+	case o.cloneTimeout <= 0 || o.fetchTimeout <= 0:
+		return nil, fmt.Errorf("timeouts must be positive")
+	case o.retries < 0:
+		return nil, fmt.Errorf("retries must not be negative")
+	case o.hostJobs < 0:
+		return nil, fmt.Errorf("host jobs must not be negative")
+	case o.retryBackoff < 0:
+		return nil, fmt.Errorf("retry backoff must not be negative")
+	}
+	return o, nil
+}
+
+func (o *syncOptions) hostLimit(host string) int {
+	if host == "" {
+		return 0
+	}
+	if jobs, ok := o.hosts[host]; ok {
+		return jobs
+	}
+	return o.hostJobs
+}
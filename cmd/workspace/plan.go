@@ -0,0 +1,103 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/paths"
+	"strings"
+)
+
+const (
+	planClone    = "clone"
+	planPull     = "pull"
+	planFetch    = "fetch"
+	planCheckout = "checkout"
+	planRegister = "register"
+	planSkip     = "skip"
+)
+
+func planProject(t *syncTask, lock *Lock, discovered bool) {
+	var action, detail, reasons, err = planAction(t, lock)
+	if err != nil {
+		t.fail(err)
+		return
+	}
+	if discovered {
+		detail = fmt.Sprintf("%s discovered at %s, then %s: %s", t.project.URL, t.project.dir(), action, detail)
+		action = planRegister
+	}
+	t.result.action = action
+	t.result.detail = detail
+	t.emit(&syncEvent{Type: eventPlan, Action: action, Detail: detail, Reasons: reasons})
+}
+
+func planAction(t *syncTask, lock *Lock) (string, string, []gits.Reason, error) {
+	var s, p = t.session, t.project
+	var entry *LockEntry
+	if lock != nil {
+		if entry = lock.find(p.Name); entry == nil {
+			return "", "", nil, fmt.Errorf("project is not locked")
+		}
+	}
+	var exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		return "", "", nil, fmt.Errorf("check project path exists error: %+v", err)
+	}
+	if !exist {
+		var detail = []string{fmt.Sprintf("%s into %s", p.URL, p.dir())}
+		if branch := p.branch(); branch != "" {
+			detail = append(detail, "branch "+branch)
+		}
+		if p.Ref != "" {
+			detail = append(detail, "ref "+p.Ref)
+		}
+		detail = append(detail, p.cloneDetail()...)
+		if mirror := t.mirror(); mirror != "" {
+			detail = append(detail, "reference "+mirror)
+		}
+		if entry != nil {
+			detail = append(detail, "locked "+shortCommit(entry.Commit))
+		}
+		return planClone, strings.Join(detail, ", "), nil, nil
+	}
+	status, err := statusProject(s, p, false)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("status project error: %+v", err)
+	}
+	if entry != nil {
+		var action, detail, err = planLocked(status, entry)
+		return action, detail, nil, err
+	}
+	if p.Ref != "" {
+		pull, warning, err := checkPinnedRef(s, p, status)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if warning != "" {
+			return planSkip, warning, nil, nil
+		}
+		if !pull {
+			return planFetch, "pinned at " + p.Ref, nil, nil
+		}
+	}
+	var strategy, autostash = p.pullStrategy(), p.autostash()
+	var decision = status.PullDecision(pullPolicy(strategy, autostash))
+	switch {
+	case decision.Allowed:
+		return planPull, fmt.Sprintf("%s, behind %d", strategy, status.Behind()), nil, nil
+	case decision.Has(gits.ReasonUpToDate):
+		return planFetch, "up to date", nil, nil
+	}
+	return planSkip, fmt.Sprintf("%s: %s", strategy, decision), decision.Reasons, nil
+}
+
+func planLocked(status *gits.Status, entry *LockEntry) (string, string, error) {
+	if status.Commit() == entry.Commit {
+		return planFetch, "at locked commit " + shortCommit(entry.Commit), nil
+	}
+	var drift = fmt.Sprintf("drift %s -> %s", shortCommit(status.Commit()), shortCommit(entry.Commit))
+	if status.IsDirty() || status.Modified() > 0 {
+		return planSkip, drift + ", local changes", nil
+	}
+	return planCheckout, drift, nil
+}
@@ -0,0 +1,83 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/panjf2000/ants/v2"
+	"sync"
+)
+
+const (
+	poolSize = 5
+)
+
+func eachProject(size int, projects []Project, fn func(p *Project)) error {
+	var wg sync.WaitGroup
+	pool, err := ants.NewPoolWithFunc(size, func(i interface{}) {
+		project := i.(Project)
+		fn(&project)
+		wg.Done()
+	})
+	if err != nil {
+		return fmt.Errorf("newPoolWithFunc error: %+v", err)
+	}
+	defer pool.Release()
+	for _, p := range projects {
+		wg.Add(1)
+		if err = pool.Invoke(p); err != nil {
+			wg.Done()
+			wg.Wait()
+			return fmt.Errorf("invoke task error: %+v", err)
+		}
+	}
+	wg.Wait()
+	return nil
+}
+
+func eachProjectByHost(size int, hostLimit func(host string) int, projects []Project, fn func(p *Project)) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var cond = sync.NewCond(&mu)
+	var active = make(map[string]int)
+	pool, err := ants.NewPoolWithFunc(size, func(i interface{}) {
+		project := i.(Project)
+		fn(&project)
+		mu.Lock()
+		active[projectHost(project.URL)]--
+		cond.Broadcast()
+		mu.Unlock()
+		wg.Done()
+	})
+	if err != nil {
+		return fmt.Errorf("newPoolWithFunc error: %+v", err)
+	}
+	defer pool.Release()
+	var pending = append([]Project(nil), projects...)
+	for len(pending) > 0 {
+		mu.Lock()
+		var index = -1
+		for index < 0 {
+			for i := range pending {
+				var host = projectHost(pending[i].URL)
+				if limit := hostLimit(host); limit <= 0 || active[host] < limit {
+					active[host]++
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				cond.Wait()
+			}
+		}
+		mu.Unlock()
+		var p = pending[index]
+		pending = append(pending[:index], pending[index+1:]...)
+		wg.Add(1)
+		if err = pool.Invoke(p); err != nil {
+			wg.Done()
+			wg.Wait()
+			return fmt.Errorf("invoke task error: %+v", err)
+		}
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,52 @@
+package workspace
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_EachProjectByHost(t *testing.T) {
+	var projects []Project
+	for i := 0; i < 6; i++ {
+		projects = append(projects, Project{Name: "a", URL: "git@a.com:x/a.git"})
+	}
+	projects = append(projects, Project{Name: "b", URL: "https://b.com/x/b.git"})
+	var limit = func(host string) int {
+		if host == "a.com" {
+			return 2
+		}
+		return 0
+	}
+	var mu sync.Mutex
+	var active, peak = make(map[string]int), make(map[string]int)
+	var order []string
+	var err = eachProjectByHost(4, limit, projects, func(p *Project) {
+		var host = projectHost(p.URL)
+		mu.Lock()
+		order = append(order, p.Name)
+		if active[host]++; active[host] > peak[host] {
+			peak[host] = active[host]
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		active[host]--
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak["a.com"] != 2 {
+		t.Errorf("peak of a.com = %d, want 2", peak["a.com"])
+	}
+	if len(order) != 7 {
+		t.Fatalf("ran %d projects, want 7", len(order))
+	}
+	for _, name := range order[:3] {
+		if name == "b" {
+			return
+		}
+	}
+	t.Errorf("project on b.com did not start early: %v", order)
+}
@@ -0,0 +1,176 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/gookit/color"
+	"github.com/pinealctx/renault/pkg/gits"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	progressInterval = 100 * time.Millisecond
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+var progressPhases = map[string]string{
+	eventStart:     "fetching",
+	eventFetch:     "checking",
+	eventClone:     "cloned",
+	eventPull:      "pulled",
+	eventCheckout:  "checked out",
+	eventSubmodule: "submodules updated",
+}
+
+type progressLine struct {
+	name    string
+	phase   string
+	started time.Time
+	status  *gits.Status
+}
+
+type progressReporter struct {
+	mu      sync.Mutex
+	active  []*progressLine
+	drawn   int
+	frame   int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func isTerminal(f *os.File) bool {
+	var info, err = f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func newProgressReporter() *progressReporter {
+	var pr = &progressReporter{
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go pr.loop()
+	return pr
+}
+
+func (pr *progressReporter) loop() {
+	defer close(pr.stopped)
+	var ticker = time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pr.stop:
+			return
+		case <-ticker.C:
+			pr.mu.Lock()
+			pr.frame = (pr.frame + 1) % len(spinnerFrames)
+			pr.redraw()
+			pr.mu.Unlock()
+		}
+	}
+}
+
+func (pr *progressReporter) event(e *syncEvent) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	var line = pr.find(e.Project)
+	switch e.Type {
+	case eventStart:
+		pr.active = append(pr.active, &progressLine{name: e.Project, phase: progressPhases[e.Type], started: e.Time})
+	case eventFetch, eventClone, eventPull, eventCheckout, eventSubmodule:
+		if line != nil {
+			line.phase = progressPhases[e.Type]
+		}
+	case eventStatus:
+		if line != nil {
+			line.status = e.status
+		}
+	case eventWarning:
+		pr.println(color.FgYellow.Sprintf("[%s] [Warning] %s", e.Project, e.Message))
+	case eventError:
+		pr.println(color.FgRed.Sprintf("[%s] %s", e.Project, firstLine(e.Error)))
+	case eventFinish:
+		pr.remove(e.Project)
+		pr.println(finishLine(e, line))
+	}
+	pr.redraw()
+}
+
+func (pr *progressReporter) done(results *syncResults) {
+	close(pr.stop)
+	<-pr.stopped
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	pr.clear()
+	printSummary(results)
+}
+
+func (pr *progressReporter) find(name string) *progressLine {
+	for _, line := range pr.active {
+		if line.name == name {
+			return line
+		}
+	}
+	return nil
+}
+
+func (pr *progressReporter) remove(name string) {
+	var active = pr.active[:0]
+	for _, line := range pr.active {
+		if line.name != name {
+			active = append(active, line)
+		}
+	}
+	pr.active = active
+}
+
+func (pr *progressReporter) println(s string) {
+	pr.clear()
+	fmt.Println(s)
+}
+
+func (pr *progressReporter) clear() {
+	if pr.drawn > 0 {
+		fmt.Printf("\x1b[%dA\x1b[J", pr.drawn)
+		pr.drawn = 0
+	}
+}
+
+func (pr *progressReporter) redraw() {
+	pr.clear()
+	var spinner = color.FgCyan.Sprint(spinnerFrames[pr.frame])
+	for _, line := range pr.active {
+		fmt.Printf("%s [%s] %s %s\n", spinner, line.name, line.phase, seconds(time.Since(line.started)))
+	}
+	pr.drawn = len(pr.active)
+}
+
+func finishLine(e *syncEvent, line *progressLine) string {
+	var glyph string
+	switch e.Action {
+	case actionFailed:
+		glyph = color.FgRed.Sprint("✘")
+	case actionSkipped, actionInterrupt:
+		glyph = color.FgYellow.Sprint("!")
+	default:
+		glyph = color.FgGreen.Sprint("✔")
+	}
+	var parts = []string{glyph, "[" + e.Project + "]", e.Action}
+	if e.Detail != "" {
+		parts = append(parts, "("+e.Detail+")")
+	}
+	if line != nil && line.status != nil {
+		parts = append(parts, line.status.Fmt())
+	}
+	parts = append(parts, seconds(time.Duration(e.DurationMS)*time.Millisecond))
+	return strings.Join(parts, " ")
+}
+
+func seconds(d time.Duration) string {
+	return color.FgGray.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,127 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/urfave/cli/v2"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var pushCommand = &cli.Command{
+	Name:    "push",
+	Aliases: []string{"p"},
+	Usage:   "Push the workspace all projects which can be pushed safely.",
+	Action:  pushWorkspace,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:    "force",
+			Aliases: []string{"f"},
+			Usage:   "Push projects even if they are behind the upstream or have a dirty index.",
+		},
+	}, selectorFlags()...),
+}
+
+type pushResult struct {
+	project *Project
+	pushed  bool
+	reason  string
+	err     error
+}
+
+func pushWorkspace(c *cli.Context) error {
+	var sel, err = newSelector(c)
+	if err != nil {
+		return err
+	}
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	timeout, err := m.Defaults.fetchTimeout()
+	if err != nil {
+		return err
+	}
+	var force = c.Bool("force")
+	var mu sync.Mutex
+	var results []pushResult
+	err = eachProject(m.Defaults.jobs(), sel.filter(m.Projects), func(p *Project) {
+		var r = pushProject(c.Context, p, force, timeout)
+		mu.Lock()
+		results = append(results, r)
+		mu.Unlock()
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].project.Name < results[j].project.Name
+	})
+	var failed int
+	var w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tRESULT\tDETAIL")
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+			failed++
+			fmt.Fprintf(w, "%s\tfailed\t%s\n", r.project.Name, firstLine(r.err.Error()))
+		case r.pushed:
+			fmt.Fprintf(w, "%s\tpushed\t%s\n", r.project.Name, r.reason)
+		default:
+			fmt.Fprintf(w, "%s\tskipped\t%s\n", r.project.Name, r.reason)
+		}
+	}
+	_ = w.Flush()
+	if failed > 0 {
+		return fmt.Errorf("%d project(s) failed to push", failed)
+	}
+	return nil
+}
+
+func pushProject(ctx context.Context, p *Project, force bool, timeout time.Duration) pushResult {
+	var r = pushResult{project: p}
+	var exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		r.err = fmt.Errorf("check project path exists error: %+v", err)
+		return r
+	}
+	if !exist {
+		r.reason = "not cloned"
+		return r
+	}
+	var s = newSession(ctx, timeout)
+
+	status, err := statusProject(s, p, true)
+	if err != nil {
+		r.err = fmt.Errorf("status project error: %+v", err)
+		return r
+	}
+	var args = []string{"push"}
+	var decision = status.PushDecision(force)
+	switch {
+	case decision.Allowed:
+		r.reason = fmt.Sprintf("%d commit(s) -> %s", status.Ahead(), status.Upstream())
+	case decision.Only(gits.ReasonNoUpstream):
+		args = append(args, "--set-upstream", p.remote(), status.Branch())
+		r.reason = fmt.Sprintf("new branch %s -> %s", status.Branch(), p.remote())
+	default:
+		r.reason = decision.String()
+		return r
+	}
+	output, err := s.CombinedOutput(args...)
+	if err != nil {
+		r.err = fmt.Errorf("git push error: %+v\n%s", err, output)
+		return r
+	}
+	r.pushed = true
+	return r
+}
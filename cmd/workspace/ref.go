@@ -0,0 +1,65 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"strings"
+)
+
+const (
+	refBranch = "branch"
+	refTag    = "tag"
+	refCommit = "commit"
+)
+
+func resolveRef(s *gits.Session, p *Project) (string, string, error) {
+	var kind, rev = refCommit, p.Ref
+	switch {
+	case gitRefExists(s, "refs/remotes/"+p.remote()+"/"+p.Ref):
+		kind, rev = refBranch, "refs/remotes/"+p.remote()+"/"+p.Ref
+	case gitRefExists(s, "refs/tags/"+p.Ref):
+		kind, rev = refTag, "refs/tags/"+p.Ref
+	}
+	var output, err = s.Output("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return "", "", fmt.Errorf("unknown ref %s", p.Ref)
+	}
+	return kind, strings.TrimSpace(string(output)), nil
+}
+
+func checkoutRef(s *gits.Session, p *Project) error {
+	s.SetDir(p.absPath())
+	var kind, commit, err = resolveRef(s, p)
+	if err != nil {
+		return err
+	}
+	var args = []string{"checkout", "--detach", commit}
+	if kind == refBranch {
+		args = []string{"checkout", p.Ref}
+		if !gitRefExists(s, "refs/heads/"+p.Ref) {
+			args = []string{"checkout", "-b", p.Ref, "--track", p.remote() + "/" + p.Ref}
+		}
+	}
+	output, err := s.CombinedOutput(args...)
+	if err != nil {
+		return fmt.Errorf("git %s error: %+v\n%s", strings.Join(args, " "), err, output)
+	}
+	return nil
+}
+
+func checkPinnedRef(s *gits.Session, p *Project, status *gits.Status) (bool, string, error) {
+	var kind, commit, err = resolveRef(s, p)
+	if err != nil {
+		return false, "", err
+	}
+	if kind == refBranch {
+		if status.Branch() != p.Ref {
+			return false, fmt.Sprintf("The working copy is on %s instead of the pinned branch %s, skip pulling.", status.Branch(), p.Ref), nil
+		}
+		return true, "", nil
+	}
+	if status.Commit() != commit {
+		return false, fmt.Sprintf("The working copy is at %s instead of the pinned %s %s.", shortCommit(status.Commit()), kind, p.Ref), nil
+	}
+	return false, "", nil
+}
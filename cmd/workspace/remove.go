@@ -0,0 +1,160 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/pinealctx/renault/pkg/share"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var removeCommand = &cli.Command{
+	Name:      "remove",
+	Aliases:   []string{"rm"},
+	Usage:     "Remove project from the workspace.",
+	ArgsUsage: "<name>",
+	Action:    removeWorkspace,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "delete",
+			Aliases: []string{"d"},
+			Usage:   "Delete the project checkout as well.",
+		},
+		&cli.BoolFlag{
+			Name:    "force",
+			Aliases: []string{"f"},
+			Usage:   "Delete the project checkout even if it contains unpushed or uncommitted work.",
+		},
+	},
+}
+
+func removeWorkspace(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("project name must be specified")
+	}
+	var name = c.Args().First()
+	var exist, err = checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	timeout, err := m.Defaults.fetchTimeout()
+	if err != nil {
+		return err
+	}
+	var index = -1
+	for i, p := range m.Projects {
+		if p.Name == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("project %s does not exist", name)
+	}
+	var p = m.Projects[index]
+	exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		return fmt.Errorf("check project path exists error: %+v", err)
+	}
+	var remove = exist && c.Bool("delete")
+	if remove {
+		if err = insideWorkspace(p.absPath()); err != nil {
+			return fmt.Errorf("refuse to delete %s: %+v", name, err)
+		}
+	}
+	if remove && !c.Bool("force") {
+		var reasons, err = unsafeToDelete(c.Context, &p, timeout)
+		if err != nil {
+			return fmt.Errorf("check project status error: %+v", err)
+		}
+		if len(reasons) > 0 {
+			return fmt.Errorf("refuse to delete %s: %s, use --force to delete anyway", name, strings.Join(reasons, ", "))
+		}
+	}
+	m.Projects = append(m.Projects[:index], m.Projects[index+1:]...)
+	if exist && !remove && !m.ignored(p.dir()) {
+		m.Ignore = append(m.Ignore, p.dir())
+	}
+	if err = saveManifest(m); err != nil {
+		return fmt.Errorf("saveManifest error: %+v", err)
+	}
+	if remove {
+		if err = os.RemoveAll(p.absPath()); err != nil {
+			return fmt.Errorf("delete project checkout error: %+v", err)
+		}
+		fmt.Printf("[%s] removed from the workspace and deleted %s.\n", name, p.absPath())
+		return nil
+	}
+	fmt.Printf("[%s] removed from the workspace.\n", name)
+	return nil
+}
+
+func unsafeToDelete(ctx context.Context, p *Project, timeout time.Duration) ([]string, error) {
+	var s = newSession(ctx, timeout)
+
+	var status, err = statusProject(s, p, false)
+	if err != nil {
+		return nil, err
+	}
+	output, err := s.Output("stash", "list")
+	if err != nil {
+		return nil, fmt.Errorf("git stash list error: %+v", err)
+	}
+	status.SetStashes(strings.Count(string(output), "\n"))
+	// HEAD counts the commits of a detached HEAD which are on no branch
+	output, err = s.Output("rev-list", "--count", "--branches", "HEAD", "--not", "--remotes")
+	if err != nil {
+		return nil, fmt.Errorf("git rev-list error: %+v", err)
+	}
+	unpushed, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return nil, fmt.Errorf("parse unpushed commits error: %+v", err)
+	}
+	return lossReasons(status, unpushed), nil
+}
+
+func insideWorkspace(pp string) error {
+	var root, err = filepath.EvalSymlinks(share.PWD)
+	if err != nil {
+		return err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(pp))
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, filepath.Join(parent, filepath.Base(pp)))
+	if err != nil {
+		return err
+	}
+	return validProjectDir(filepath.ToSlash(rel))
+}
+
+func lossReasons(status *gits.Status, unpushed int) []string {
+	var reasons []string
+	if status.Ahead() > unpushed {
+		unpushed = status.Ahead()
+	}
+	if unpushed > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d unpushed commit(s)", unpushed))
+	}
+	if status.Stashes() > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d stash(es)", status.Stashes()))
+	}
+	if status.UnTracked() > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d untracked file(s)", status.UnTracked()))
+	}
+	if n := status.Staged() + status.Modified() + status.Unmerged(); n > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d modified file(s)", n))
+	}
+	return reasons
+}
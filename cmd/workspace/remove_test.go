@@ -0,0 +1,53 @@
+package workspace
+
+import (
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/share"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_LossReasons(t *testing.T) {
+	var status = gits.NewStatus(t.TempDir())
+	var err = status.Parse([]byte("# branch.oid 1234567890\n# branch.head master\n# branch.upstream origin/master\n# branch.ab +1 -0\n" +
+		"1 M. N... 100644 100644 100644 abc abc a.go\n" +
+		"1 .M N... 100644 100644 100644 abc abc b.go\n" +
+		"? c.go\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.SetStashes(2)
+	var want = []string{"3 unpushed commit(s)", "2 stash(es)", "1 untracked file(s)", "2 modified file(s)"}
+	if got := lossReasons(status, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("lossReasons() = %v, want %v", got, want)
+	}
+	want[0] = "1 unpushed commit(s)"
+	if got := lossReasons(status, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("lossReasons() = %v, want %v", got, want)
+	}
+	if got := lossReasons(gits.NewStatus(t.TempDir()), 0); len(got) != 0 {
+		t.Errorf("lossReasons() of a clean status = %v, want none", got)
+	}
+}
+
+func Test_InsideWorkspace(t *testing.T) {
+	share.PWD = t.TempDir()
+	var outside = t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(share.PWD, "libs")); err != nil {
+		t.Fatal(err)
+	}
+	for pp, inside := range map[string]bool{
+		share.ProjectAbsolutePath("user"):      true,
+		share.ProjectAbsolutePath("libs"):      true,
+		share.ProjectAbsolutePath("libs/user"): false,
+		share.PWD:                              false,
+		filepath.Dir(share.PWD):                false,
+		filepath.Join(outside, "user"):         false,
+	} {
+		if err := insideWorkspace(pp); (err == nil) != inside {
+			t.Errorf("insideWorkspace(%q) = %v, want inside %v", pp, err, inside)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	eventStart     = "start"
+	eventFetch     = "fetch"
+	eventClone     = "clone"
+	eventPull      = "pull"
+	eventCheckout  = "checkout"
+	eventSubmodule = "submodule"
+	eventStatus    = "status"
+	eventPlan      = "plan"
+	eventWarning   = "warning"
+	eventError     = "error"
+	eventFinish    = "finish"
+	eventDone      = "done"
+)
+
+type syncEvent struct {
+	Type       string        `json:"type"`
+	Project    string        `json:"project,omitempty"`
+	Time       time.Time     `json:"time"`
+	DurationMS int64         `json:"duration_ms,omitempty"`
+	Action     string        `json:"action,omitempty"`
+	Detail     string        `json:"detail,omitempty"`
+	Message    string        `json:"message,omitempty"`
+	OldCommit  string        `json:"old_commit,omitempty"`
+	NewCommit  string        `json:"new_commit,omitempty"`
+	Status     *gits.Info    `json:"status,omitempty"`
+	Reasons    []gits.Reason `json:"reasons,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Failed     int           `json:"failed,omitempty"`
+	Total      int           `json:"total,omitempty"`
+
+	status *gits.Status
+	output string
+}
+
+type reporter interface {
+	event(e *syncEvent)
+	done(results *syncResults)
+}
+
+func newReporter(output string, progress bool) (reporter, error) {
+	switch output {
+	case "text":
+		if progress && isTerminal(os.Stdout) {
+			return newProgressReporter(), nil
+		}
+		return &textReporter{}, nil
+	case "json":
+		return &jsonReporter{enc: json.NewEncoder(os.Stdout)}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", output)
+}
+
+type textReporter struct {
+	mu sync.Mutex
+}
+
+func (t *textReporter) event(e *syncEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch e.Type {
+	case eventClone:
+		fmt.Printf("[%s] git clone success: %s", e.Project, e.output)
+	case eventPull:
+		fmt.Printf("[%s] git pull (%s) success.\n", e.Project, e.Detail)
+	case eventCheckout:
+		fmt.Printf("[%s] %s\n", e.Project, e.Message)
+	case eventSubmodule:
+		fmt.Printf("[%s] git submodule update (%s) success.\n", e.Project, e.Detail)
+	case eventStatus:
+		fmt.Printf("[%s] git status: %s\n", e.Project, e.status.Fmt())
+	case eventWarning:
+		fmt.Printf("[%s] [Warning] %s\n", e.Project, e.Message)
+	case eventError:
+		fmt.Printf("[%s] %s\n", e.Project, e.Error)
+	}
+}
+
+func (t *textReporter) done(results *syncResults) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	printSummary(results)
+}
+
+func printSummary(results *syncResults) {
+	results.print()
+	switch {
+	case results.dryRun:
+		fmt.Println("Dry run completed, remote state is as of the last fetch and nothing was changed.")
+	case results.failed() == 0 && results.interrupted() == 0:
+		fmt.Println("Workspace synchronization completed.")
+	}
+}
+
+type jsonReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (j *jsonReporter) event(e *syncEvent) {
+	if e.status != nil {
+		var info = e.status.Info()
+		e.Status = &info
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_ = j.enc.Encode(e)
+}
+
+func (j *jsonReporter) done(results *syncResults) {
+	j.event(&syncEvent{
+		Type:   eventDone,
+		Time:   time.Now(),
+		Failed: results.failed(),
+		Total:  len(results.list),
+	})
+}
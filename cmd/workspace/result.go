@@ -0,0 +1,101 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"os"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+const (
+	actionCloned     = "cloned"
+	actionPulled     = "pulled"
+	actionCheckedOut = "checked-out"
+	actionUpToDate   = "up-to-date"
+	actionSkipped    = "skipped"
+	actionFailed     = "failed"
+	actionInterrupt  = "interrupted"
+)
+
+type syncResult struct {
+	project *Project
+	action  string
+	detail  string
+	status  *gits.Status
+	err     error
+}
+
+func newSyncResult(p *Project) *syncResult {
+	return &syncResult{project: p, action: actionUpToDate}
+}
+
+func (r *syncResult) skip(format string, args ...interface{}) *syncResult {
+	r.action = actionSkipped
+	r.detail = fmt.Sprintf(format, args...)
+	return r
+}
+
+func (r *syncResult) fail(err error) *syncResult {
+	r.action = actionFailed
+	r.err = err
+	return r
+}
+
+func (r *syncResult) interrupt() *syncResult {
+	r.action = actionInterrupt
+	r.detail = ""
+	r.err = nil
+	return r
+}
+
+type syncResults struct {
+	mu     sync.Mutex
+	list   []*syncResult
+	dryRun bool
+}
+
+func (rs *syncResults) add(r *syncResult) {
+	rs.mu.Lock()
+	rs.list = append(rs.list, r)
+	rs.mu.Unlock()
+}
+
+func (rs *syncResults) failed() int {
+	return rs.count(actionFailed)
+}
+
+func (rs *syncResults) interrupted() int {
+	return rs.count(actionInterrupt)
+}
+
+func (rs *syncResults) count(action string) int {
+	var n int
+	for _, r := range rs.list {
+		if r.action == action {
+			n++
+		}
+	}
+	return n
+}
+
+func (rs *syncResults) print() {
+	sort.Slice(rs.list, func(i, j int) bool {
+		return rs.list[i].project.Name < rs.list[j].project.Name
+	})
+	var w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	var header = "PROJECT\tRESULT\tDETAIL"
+	if rs.dryRun {
+		header = "PROJECT\tPLAN\tDETAIL"
+	}
+	fmt.Fprintln(w, header)
+	for _, r := range rs.list {
+		var detail = r.detail
+		if r.err != nil {
+			detail = firstLine(r.err.Error())
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.project.Name, r.action, orDash(detail))
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,71 @@
+package workspace
+
+import (
+	"strings"
+	"time"
+)
+
+var transientErrors = []string{
+	"timeout after",
+	"could not resolve host",
+	"temporary failure in name resolution",
+	"connection timed out",
+	"operation timed out",
+	"connection reset",
+	"connection refused",
+	"the remote end hung up unexpectedly",
+	"early eof",
+	"unexpected disconnect",
+	"rpc failed",
+	"gnutls_handshake",
+	"ssl_error",
+	"returned error: 429",
+	"returned error: 500",
+	"returned error: 502",
+	"returned error: 503",
+	"returned error: 504",
+}
+
+func isTransient(err error, output []byte) bool {
+	var msg = strings.ToLower(err.Error() + "\n" + string(output))
+	for _, s := range transientErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *syncTask) retry(name string, fn func() ([]byte, error)) ([]byte, error) {
+	var backoff = t.options.retryBackoff
+	for attempt := 1; ; attempt++ {
+		var output, err = fn()
+		if err == nil || attempt > t.options.retries || t.ctx.Err() != nil || !isTransient(err, output) {
+			return output, err
+		}
+		t.warn("git %s failed, retry %d/%d in %s: %s", name, attempt, t.options.retries, backoff, failureLine(err, output))
+		select {
+		case <-t.ctx.Done():
+			return output, t.ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+func failureLine(err error, output []byte) string {
+	var lines = strings.Split(strings.TrimSpace(string(output)), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return last
+	}
+	return err.Error()
+}
+
+func (t *syncTask) git(timeout time.Duration, args ...string) ([]byte, error) {
+	var s = t.session.WithTimeout(timeout)
+	return t.retry(args[0], func() ([]byte, error) {
+		return s.CombinedOutput(args...)
+	})
+}
@@ -0,0 +1,28 @@
+package workspace
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_IsTransient(t *testing.T) {
+	var err = errors.New("exit status 128")
+	var cases = []struct {
+		output string
+		want   bool
+	}{
+		{"fatal: unable to access 'https://example.com/a.git/': Could not resolve host: example.com", true},
+		{"error: RPC failed; curl 18 transfer closed\nfatal: early EOF", true},
+		{"fatal: unable to access 'https://example.com/a.git/': The requested URL returned error: 503", true},
+		{"fatal: repository 'https://example.com/a.git/' not found", false},
+		{"fatal: Not possible to fast-forward, aborting.", false},
+	}
+	for _, c := range cases {
+		if got := isTransient(err, []byte(c.output)); got != c.want {
+			t.Errorf("isTransient(%q) = %v, want %v", c.output, got, c.want)
+		}
+	}
+	if !isTransient(errors.New("timeout after 2m0s"), nil) {
+		t.Errorf("timeout should be transient")
+	}
+}
@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"path"
+)
+
+type selector struct {
+	groups   []string
+	tags     []string
+	names    []string
+	excludes []string
+}
+
+func selectorFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:    "group",
+			Aliases: []string{"g"},
+			Usage:   "Only select projects in the group, can be repeated.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "Only select projects with the tag, can be repeated.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "name",
+			Usage: "Only select projects whose name matches the glob, can be repeated.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude",
+			Usage: "Skip projects whose name matches the glob, can be repeated.",
+		},
+	}
+}
+
+func newSelector(c *cli.Context) (*selector, error) {
+	var s = &selector{
+		groups:   c.StringSlice("group"),
+		tags:     c.StringSlice("tag"),
+		names:    c.StringSlice("name"),
+		excludes: c.StringSlice("exclude"),
+	}
+	for _, patterns := range [][]string{s.names, s.excludes} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %+v", pattern, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s *selector) match(p *Project) bool {
+	if len(s.groups) > 0 && !containsAny(p.Groups, s.groups) {
+		return false
+	}
+	if len(s.tags) > 0 && !containsAny(p.Tags, s.tags) {
+		return false
+	}
+	if len(s.names) > 0 && !matchAny(p.Name, s.names) {
+		return false
+	}
+	return !matchAny(p.Name, s.excludes)
+}
+
+func (s *selector) filter(projects []Project) []Project {
+	var selected = make([]Project, 0, len(projects))
+	for _, p := range projects {
+		if s.match(&p) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package workspace
+
+import "testing"
+
+func Test_SelectorMatch(t *testing.T) {
+	var projects = []Project{
+		{Name: "user", Groups: []string{"backend"}, Tags: []string{"go"}},
+		{Name: "user-web", Groups: []string{"frontend"}},
+		{Name: "terraform", Groups: []string{"infra"}, Tags: []string{"ops"}},
+		{Name: "order", Groups: []string{"backend"}},
+	}
+	var cases = []struct {
+		sel  selector
+		want []string
+	}{
+		{sel: selector{}, want: []string{"user", "user-web", "terraform", "order"}},
+		{sel: selector{groups: []string{"backend", "infra"}}, want: []string{"user", "terraform", "order"}},
+		{sel: selector{groups: []string{"backend"}, tags: []string{"go"}}, want: []string{"user"}},
+		{sel: selector{names: []string{"user*"}}, want: []string{"user", "user-web"}},
+		{sel: selector{groups: []string{"backend"}, excludes: []string{"ord?r"}}, want: []string{"user"}},
+	}
+	for i, c := range cases {
+		var got = c.sel.filter(projects)
+		if len(got) != len(c.want) {
+			t.Fatalf("case %d: got %d projects, want %v", i, len(got), c.want)
+		}
+		for j, p := range got {
+			if p.Name != c.want[j] {
+				t.Fatalf("case %d: got %s, want %s", i, p.Name, c.want[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/paths"
+	"github.com/urfave/cli/v2"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+var statusCommand = &cli.Command{
+	Name:    "status",
+	Aliases: []string{"st"},
+	Usage:   "Show the status of the workspace all projects.",
+	Action:  statusWorkspace,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "no-fetch",
+			Usage: "Do not fetch from the remote before collecting status.",
+		},
+		&cli.BoolFlag{
+			Name:  "dirty-only",
+			Usage: "Only show projects with uncommitted changes.",
+		},
+		&cli.StringFlag{
+			Name:  "sort",
+			Usage: "Sort by name, branch, ahead, behind or dirty.",
+			Value: "name",
+		},
+	}, selectorFlags()...),
+}
+
+type projectStatus struct {
+	project *Project
+	status  *gits.Status
+	err     error
+}
+
+func statusWorkspace(c *cli.Context) error {
+	var sel, err = newSelector(c)
+	if err != nil {
+		return err
+	}
+	var less, ok = statusSorters[c.String("sort")]
+	if !ok {
+		return fmt.Errorf("unknown sort key %q", c.String("sort"))
+	}
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	m, err := loadManifest()
+	if err != nil {
+		return fmt.Errorf("loadManifest error: %+v", err)
+	}
+	timeout, err := m.Defaults.fetchTimeout()
+	if err != nil {
+		return err
+	}
+	var fetch = !c.Bool("no-fetch")
+	var mu sync.Mutex
+	var results []projectStatus
+	err = eachProject(m.Defaults.jobs(), sel.filter(m.Projects), func(p *Project) {
+		var status, err = collectStatus(c.Context, p, fetch, timeout)
+		mu.Lock()
+		results = append(results, projectStatus{project: p, status: status, err: err})
+		mu.Unlock()
+	})
+	if err != nil {
+		return err
+	}
+	if c.Bool("dirty-only") {
+		var dirty = results[:0]
+		for _, r := range results {
+			if r.status != nil && r.status.HasChanges() {
+				dirty = append(dirty, r)
+			}
+		}
+		results = dirty
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return less(&results[i], &results[j])
+	})
+	printStatus(results)
+	return nil
+}
+
+func collectStatus(ctx context.Context, p *Project, fetch bool, timeout time.Duration) (*gits.Status, error) {
+	var exist, err = paths.Exists(p.absPath())
+	if err != nil {
+		return nil, fmt.Errorf("check project path exists error: %+v", err)
+	}
+	if !exist {
+		return nil, fmt.Errorf("not cloned")
+	}
+	var s = newSession(ctx, timeout)
+	return statusProject(s, p, fetch)
+}
+
+func newSession(ctx context.Context, timeout time.Duration) *gits.Session {
+	return gits.NewSession(ctx).SetTimeout(timeout)
+}
+
+func statusProject(s *gits.Session, p *Project, fetch bool) (*gits.Status, error) {
+	var pp = p.absPath()
+	if fetch {
+		if err := fetchProject(s, p); err != nil {
+			return nil, err
+		}
+	}
+	s.SetDir(pp)
+	var output, err = s.CombinedOutput("status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil, fmt.Errorf("git status error: %+v", err)
+	}
+	var status = gits.NewStatus(pp)
+	if err = status.Parse(output); err != nil {
+		return nil, fmt.Errorf("parse git status error: %+v", err)
+	}
+	output, err = s.CombinedOutput("describe", "--tags")
+	if err == nil {
+		status.SetTag(strings.TrimRight(string(output), "\n"))
+	}
+	return status, nil
+}
+
+func fetchProject(s *gits.Session, p *Project) error {
+	s.SetDir(p.absPath())
+	var output, err = s.CombinedOutput("fetch")
+	if err != nil {
+		return fmt.Errorf("git fetch error: %+v\n%s", err, output)
+	}
+	return nil
+}
+
+var statusSorters = map[string]func(a, b *projectStatus) bool{
+	"name": func(a, b *projectStatus) bool {
+		return a.project.Name < b.project.Name
+	},
+	"branch": func(a, b *projectStatus) bool {
+		return statusBranch(a) < statusBranch(b)
+	},
+	"ahead": func(a, b *projectStatus) bool {
+		return statusCount(a, (*gits.Status).Ahead) > statusCount(b, (*gits.Status).Ahead)
+	},
+	"behind": func(a, b *projectStatus) bool {
+		return statusCount(a, (*gits.Status).Behind) > statusCount(b, (*gits.Status).Behind)
+	},
+	"dirty": func(a, b *projectStatus) bool {
+		return statusCount(a, changedCount) > statusCount(b, changedCount)
+	},
+}
+
+func statusBranch(r *projectStatus) string {
+	if r.status == nil {
+		return ""
+	}
+	return r.status.Branch()
+}
+
+func statusCount(r *projectStatus, fn func(*gits.Status) int) int {
+	if r.status == nil {
+		return -1
+	}
+	return fn(r.status)
+}
+
+func changedCount(status *gits.Status) int {
+	return status.Staged() + status.Modified() + status.UnTracked() + status.Unmerged()
+}
+
+func printStatus(results []projectStatus) {
+	var w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT\tBRANCH\tTAG\tAHEAD\tBEHIND\tDIRTY")
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t%s\n", r.project.Name, firstLine(r.err.Error()))
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\n", r.project.Name, r.status.Branch(),
+			orDash(r.status.Tag()), r.status.Ahead(), r.status.Behind(), dirtySummary(r.status))
+	}
+	_ = w.Flush()
+}
+
+func dirtySummary(status *gits.Status) string {
+	var parts []string
+	for _, item := range []struct {
+		name  string
+		count int
+	}{
+		{"staged", status.Staged()},
+		{"modified", status.Modified()},
+		{"untracked", status.UnTracked()},
+		{"unmerged", status.Unmerged()},
+		{"submodules", status.Submodules()},
+	} {
+		if item.count > 0 {
+			parts = append(parts, item.name+":"+strconv.Itoa(item.count))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
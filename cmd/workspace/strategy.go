@@ -0,0 +1,58 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+)
+
+const (
+	pullFFOnly = "ff-only"
+	pullRebase = "rebase"
+	pullMerge  = "merge"
+)
+
+func (p *Project) pullStrategy() string {
+	if p.PullStrategy != "" {
+		return p.PullStrategy
+	}
+	if p.defaults != nil && p.defaults.PullStrategy != "" {
+		return p.defaults.PullStrategy
+	}
+	return pullFFOnly
+}
+
+func (p *Project) autostash() bool {
+	if p.Autostash != nil {
+		return *p.Autostash
+	}
+	if p.defaults != nil && p.defaults.Autostash != nil {
+		return *p.defaults.Autostash
+	}
+	return false
+}
+
+func pullArgs(strategy string, autostash bool) ([]string, error) {
+	var args = []string{"pull"}
+	switch strategy {
+	case pullFFOnly:
+		args = append(args, "--ff-only")
+	case pullRebase:
+		args = append(args, "--rebase")
+	case pullMerge:
+		args = append(args, "--no-rebase", "--no-edit")
+	default:
+		return nil, fmt.Errorf("unknown pull strategy %q, must be one of %s, %s, %s", strategy, pullFFOnly, pullRebase, pullMerge)
+	}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	return args, nil
+}
+
+func pullPolicy(strategy string, autostash bool) gits.PullPolicy {
+	return gits.PullPolicy{
+		AllowDiverged:  strategy != pullFFOnly,
+		AllowDirty:     autostash,
+		AllowUntracked: true,
+	}
+}
@@ -0,0 +1,67 @@
+package workspace
+
+import (
+	"fmt"
+	"github.com/pinealctx/renault/pkg/gits"
+	"github.com/pinealctx/renault/pkg/paths"
+	"path/filepath"
+	"time"
+)
+
+const (
+	submodulesOff       = "off"
+	submodulesOn        = "on"
+	submodulesRecursive = "recursive"
+)
+
+func (p *Project) submodules() string {
+	if p.Submodules != "" {
+		return p.Submodules
+	}
+	if p.defaults != nil && p.defaults.Submodules != "" {
+		return p.defaults.Submodules
+	}
+	return submodulesRecursive
+}
+
+func validateSubmodules(mode string) error {
+	switch mode {
+	case submodulesOff, submodulesOn, submodulesRecursive:
+		return nil
+	}
+	return fmt.Errorf("unknown submodules mode %q, must be one of %s, %s, %s", mode, submodulesOff, submodulesOn, submodulesRecursive)
+}
+
+func (t *syncTask) updateSubmodules(status *gits.Status) error {
+	var p = t.project
+	var mode = p.submodules()
+	if mode == submodulesOff {
+		return nil
+	}
+	var exist, err = paths.Exists(filepath.Join(p.absPath(), ".gitmodules"))
+	if err != nil || !exist {
+		return err
+	}
+	if status != nil && (status.SubmoduleCommits() > 0 || status.SubmoduleModified() > 0) {
+		t.warn("Skip updating submodules with local changes.")
+		return nil
+	}
+	var start = time.Now()
+	var syncArgs = []string{"submodule", "sync"}
+	var updateArgs = []string{"submodule", "update", "--init"}
+	if mode == submodulesRecursive {
+		syncArgs = append(syncArgs, "--recursive")
+		updateArgs = append(updateArgs, "--recursive")
+	}
+	t.session.SetDir(p.absPath())
+	output, err := t.session.CombinedOutput(syncArgs...)
+	if err != nil {
+		return fmt.Errorf("git submodule sync error: %+v\n%s", err, output)
+	}
+	output, err = t.git(t.options.cloneTimeout, updateArgs...)
+	if err != nil {
+		return fmt.Errorf("git submodule update error: %+v\n%s", err, output)
+	}
+	t.emit(&syncEvent{Type: eventSubmodule, DurationMS: sinceMS(start), Detail: mode})
+	return nil
+}
@@ -1,116 +1,255 @@
 package workspace
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"github.com/codeskyblue/go-sh"
-	"github.com/panjf2000/ants/v2"
 	"github.com/pinealctx/renault/pkg/gits"
 	"github.com/pinealctx/renault/pkg/paths"
 	"github.com/pinealctx/renault/pkg/share"
 	"github.com/urfave/cli/v2"
-	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 )
 
-const (
-	poolSize = 5
-)
-
 var syncCommand = &cli.Command{
 	Name:   "sync",
 	Usage:  "Sync the workspace all projects.",
 	Action: syncWorkspace,
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "locked",
+			Usage: "Check out every project at the commit recorded in .renault/lock.yaml.",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print what sync would do for each project without changing anything.",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Specify the output format: text or json (one event per line).",
+			Value:   "text",
+		},
+		&cli.BoolFlag{
+			Name:  "no-progress",
+			Usage: "Disable the live progress display and print one line per event.",
+		},
+	}, append(syncOptionFlags(), selectorFlags()...)...),
+}
+
+type syncTask struct {
+	ctx     context.Context
+	project *Project
+	session *gits.Session
+	options *syncOptions
+	result  *syncResult
+	report  reporter
+	started time.Time
+}
+
+func newSyncTask(ctx context.Context, p *Project, options *syncOptions, report reporter) *syncTask {
+	return &syncTask{
+		ctx:     ctx,
+		project: p,
+		session: newSession(ctx, options.fetchTimeout),
+		options: options,
+		result:  newSyncResult(p),
+		report:  report,
+		started: time.Now(),
+	}
+}
+
+func (t *syncTask) emit(e *syncEvent) {
+	e.Project = t.project.Name
+	e.Time = time.Now()
+	t.report.event(e)
+}
+
+func (t *syncTask) warn(format string, args ...interface{}) {
+	t.emit(&syncEvent{Type: eventWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (t *syncTask) fail(err error) {
+	if t.ctx.Err() != nil {
+		t.result.interrupt()
+		return
+	}
+	t.emit(&syncEvent{Type: eventError, Error: err.Error()})
+	t.result.fail(err)
+}
+
+func (t *syncTask) emitStatus(status *gits.Status) {
+	t.result.status = status
+	t.emit(&syncEvent{Type: eventStatus, status: status})
+}
+
+func (t *syncTask) finish() *syncResult {
+	var e = &syncEvent{
+		Type:       eventFinish,
+		DurationMS: sinceMS(t.started),
+		Action:     t.result.action,
+		Detail:     t.result.detail,
+	}
+	if t.result.err != nil {
+		e.Error = t.result.err.Error()
+	}
+	t.emit(e)
+	return t.result
 }
 
-func syncWorkspace(*cli.Context) error {
-	var renaultPath = share.RenaultAbsolutePath()
-	var exist, err = paths.Exists(renaultPath)
+func sinceMS(start time.Time) int64 {
+	return time.Since(start).Milliseconds()
+}
+
+func syncWorkspace(c *cli.Context) error {
+	var ctx, stop = signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// restore the default handler so that a second Ctrl-C exits at once
+		<-ctx.Done()
+		stop()
+	}()
+	var sel, err = newSelector(c)
 	if err != nil {
-		return fmt.Errorf("check renault path exists error: %+v", err)
+		return err
 	}
-	if !exist {
-		fmt.Println("Workspace don't initialize.")
-		return nil
+	exist, err := checkWorkspace()
+	if err != nil || !exist {
+		return err
+	}
+	var dryRun = c.Bool("dry-run")
+	var m *Manifest
+	if dryRun {
+		m, _, err = readManifest()
+	} else {
+		m, err = loadManifest()
 	}
-	projects, err := loadProjects()
 	if err != nil {
-		return fmt.Errorf("loadProjects error: %+v", err)
+		return fmt.Errorf("loadManifest error: %+v", err)
 	}
-	dirs, err := os.ReadDir(share.PWD)
+	discovered, err := discoverProjects(share.PWD, m.Projects, m.Ignore)
 	if err != nil {
-		return fmt.Errorf("readDir error: %+v", err)
+		return fmt.Errorf("discoverProjects error: %+v", err)
 	}
-	var changed bool
-	var hash = make(map[string]struct{})
-	for _, p := range projects {
-		hash[p.Name] = struct{}{}
+	var changed = len(discovered) > 0
+	m.Projects = append(m.Projects, discovered...)
+	m.bind()
+	for _, p := range m.Projects {
+		if _, err = pullArgs(p.pullStrategy(), false); err != nil {
+			return fmt.Errorf("project %s: %+v", p.Name, err)
+		}
+		if err = p.validateClone(); err != nil {
+			return fmt.Errorf("project %s: %+v", p.Name, err)
+		}
+		if err = validateSubmodules(p.submodules()); err != nil {
+			return fmt.Errorf("project %s: %+v", p.Name, err)
+		}
 	}
-	for _, dir := range dirs {
-		if strings.HasPrefix(dir.Name(), ".") {
-			continue
+	options, err := newSyncOptions(c, &m.Defaults)
+	if err != nil {
+		return err
+	}
+	var lock *Lock
+	if c.Bool("locked") {
+		exist, err = paths.Exists(share.LockAbsoluteFile())
+		if err != nil {
+			return fmt.Errorf("check lock file exists error: %+v", err)
 		}
-		var _, ok = hash[dir.Name()]
-		if !ok {
-			var url = getGitURL(share.ProjectAbsolutePath(dir.Name()))
-			if url != "" {
-				projects = append(projects, Project{
-					Name: getGitName(url),
-					URL:  url,
-				})
-				changed = true
-			}
-		}
-	}
-	var wg sync.WaitGroup
-	pool, err := ants.NewPoolWithFunc(poolSize, func(i interface{}) {
-		project := i.(Project)
-		syncGitProject(&project)
-		wg.Done()
-	})
+		if !exist {
+			return fmt.Errorf("lock file %s not found, run lock first", share.LockAbsoluteFile())
+		}
+		if lock, err = loadLock(); err != nil {
+			return fmt.Errorf("loadLock error: %+v", err)
+		}
+	}
+	var syncFn = func(t *syncTask) {
+		switch {
+		case dryRun:
+			planProject(t, lock, isDiscovered(discovered, t.project))
+		case lock != nil:
+			syncLockedProject(t, lock.find(t.project.Name))
+		default:
+			syncGitProject(t)
+		}
+	}
+	// the progress reporter runs a goroutine until done, create it once nothing can return early
+	report, err := newReporter(c.String("output"), !c.Bool("no-progress"))
 	if err != nil {
-		return fmt.Errorf("newPoolWithFunc error: %+v", err)
+		return err
 	}
-	defer pool.Release()
-	for _, p := range projects {
-		wg.Add(1)
-		if err = pool.Invoke(p); err != nil {
-			return fmt.Errorf("invoke task error: %+v", err)
+	var results = syncResults{dryRun: dryRun}
+	err = eachProjectByHost(options.jobs, options.hostLimit, sel.filter(m.Projects), func(p *Project) {
+		var t = newSyncTask(ctx, p, options, report)
+		if ctx.Err() != nil {
+			t.result.interrupt()
+			results.add(t.finish())
+			return
 		}
+		t.emit(&syncEvent{Type: eventStart})
+		syncFn(t)
+		results.add(t.finish())
+	})
+	if err != nil {
+		report.done(&results)
+		return err
 	}
-	wg.Wait()
-	if changed {
-		if err = saveProjects(projects); err != nil {
-			return fmt.Errorf("saveProjects error: %+v", err)
+	if changed && !dryRun {
+		if err = saveManifest(m); err != nil {
+			return fmt.Errorf("saveManifest error: %+v", err)
 		}
 	}
-	fmt.Println("Workspace synchronization completed.")
+	report.done(&results)
+	if ctx.Err() != nil {
+		return cli.Exit("Workspace synchronization interrupted.", 130)
+	}
+	if failed := results.failed(); failed > 0 {
+		return cli.Exit(fmt.Sprintf("Workspace synchronization failed: %d project(s) failed.", failed), 1)
+	}
 	return nil
 }
 
-func syncGitProject(p *Project) {
-	var s = sh.NewSession()
-	s.SetTimeout(time.Second * 15)
-	defer s.Kill(os.Kill)
+func isDiscovered(discovered []Project, p *Project) bool {
+	for _, d := range discovered {
+		if d.Name == p.Name {
+			return true
+		}
+	}
+	return false
+}
 
-	var latest, err = cloneProject(s, p)
+func syncGitProject(t *syncTask) {
+	var cloned, err = cloneProject(t)
 	if err != nil {
-		fmt.Printf("[%s] clone project error: %+v\n", p.Name, err)
+		t.fail(fmt.Errorf("clone project error: %+v", err))
 		return
 	}
-	if latest {
+	if cloned {
+		return
+	}
+	if t.usesMirror() {
+		t.updateMirror()
+	}
+	if err = addRemotes(t.session, t.project); err != nil {
+		t.warn("add remotes error: %+v", err)
+	}
+	if err = t.sparseCheckout(false); err != nil {
+		t.fail(err)
 		return
 	}
-	if err = pullProject(s, p); err != nil {
-		fmt.Printf("[%s] pull project error: %+v\n", p.Name, err)
+	if err = pullProject(t); err != nil {
+		t.fail(fmt.Errorf("pull project error: %+v", err))
 	}
 }
 
-func cloneProject(s *sh.Session, p *Project) (bool, error) {
-	var pp = share.ProjectAbsolutePath(p.Name)
+func cloneProject(t *syncTask) (bool, error) {
+	var s, p = t.session, t.project
+	var pp = p.absPath()
 	var exists, err = paths.Exists(pp)
 	if err != nil {
 		return false, fmt.Errorf("cloneProject exists error: %+v", err)
@@ -118,88 +257,200 @@ func cloneProject(s *sh.Session, p *Project) (bool, error) {
 	if exists {
 		var url = getGitURL(pp)
 		if url != p.URL {
-			fmt.Printf("[%s] [Warning] The git project url does not match the configured url.\n", p.Name)
+			t.warn("The git project url does not match the configured url.")
 		}
 		return false, nil
 	}
-	s.SetDir(share.PWD)
-	out, err := s.Command("git", "clone", p.URL, p.Name).CombinedOutput()
-	if err != nil {
-		return false, fmt.Errorf("git clone error: %+v", err)
+	var parent, base = filepath.Dir(pp), filepath.Base(pp)
+	if err = os.MkdirAll(parent, 0755); err != nil {
+		return false, fmt.Errorf("cloneProject mkdir error: %+v", err)
 	}
-	fmt.Printf("[%s] git clone success: %s", p.Name, out)
-	return true, nil
-}
-
-func pullProject(s *sh.Session, p *Project) error {
-	var pp = share.ProjectAbsolutePath(p.Name)
-	s.SetDir(pp)
-	var status, err = statusProject(s, p)
+	removePartialClones(parent, base)
+	tmp, err := ioutil.TempDir(parent, partialClonePrefix(base))
 	if err != nil {
-		return fmt.Errorf("status project error: %+v", err)
+		return false, fmt.Errorf("cloneProject temp dir error: %+v", err)
 	}
-
 	defer func() {
-		fmt.Printf("[%s] git status: %s\n", p.Name, status.Fmt())
+		if tmp != "" {
+			_ = os.RemoveAll(tmp)
+		}
 	}()
-
-	if !status.CanPull(true) {
-		return nil
+	var start = time.Now()
+	s.SetDir(parent)
+	var args = p.cloneArgs()
+	if mirror := t.updateMirror(); mirror != "" {
+		args = append(args, "--reference-if-able", mirror)
 	}
-	_, err = s.Command("git", "pull").CombinedOutput()
+	args = append(args, p.URL, tmp)
+	var clone = s.WithTimeout(t.options.cloneTimeout)
+	out, err := t.retry("clone", func() ([]byte, error) {
+		if err := resetDir(tmp); err != nil {
+			return nil, err
+		}
+		// show the project dir instead of the temp dir, in the retry warnings as well
+		var out, err = clone.CombinedOutput(args...)
+		return bytes.Replace(out, []byte(tmp), []byte(p.dir()), -1), err
+	})
+	var output = string(out)
 	if err != nil {
-		return fmt.Errorf("git pull project error: %+v", err)
+		return false, fmt.Errorf("git clone error: %+v\n%s", err, output)
+	}
+	if err = os.Rename(tmp, pp); err != nil {
+		return false, fmt.Errorf("cloneProject rename error: %+v", err)
+	}
+	tmp = ""
+	// an empty repository has no HEAD yet, the clone is reported without commit
+	head, _ := s.SetDir(pp).Output("rev-parse", "HEAD")
+	t.emit(&syncEvent{
+		Type:       eventClone,
+		DurationMS: sinceMS(start),
+		NewCommit:  strings.TrimSpace(string(head)),
+		output:     output,
+	})
+	if err = t.sparseCheckout(true); err != nil {
+		return true, err
+	}
+	if err = addRemotes(s, p); err != nil {
+		return true, fmt.Errorf("add remotes error: %+v", err)
+	}
+	if p.Ref != "" {
+		if err = checkoutRef(s, p); err != nil {
+			return true, fmt.Errorf("checkout ref error: %+v", err)
+		}
 	}
-	fmt.Printf("[%s] git pull success.\n", p.Name)
-	status, err = statusProject(s, p)
+	if err = t.updateSubmodules(nil); err != nil {
+		return true, err
+	}
+	status, err := statusProject(s, p, false)
 	if err != nil {
-		return fmt.Errorf("status project agin error: %+v", err)
+		return true, fmt.Errorf("status project error: %+v", err)
+	}
+	t.result.action = actionCloned
+	t.emitStatus(status)
+	return true, nil
+}
+
+func partialClonePrefix(base string) string {
+	return "." + base + ".clone-"
+}
+
+func removePartialClones(parent, base string) {
+	var matches, _ = filepath.Glob(filepath.Join(parent, partialClonePrefix(base)+"*"))
+	for _, m := range matches {
+		_ = os.RemoveAll(m)
 	}
-	status.SetNewPull()
-	return nil
 }
 
-func statusProject(s *sh.Session, p *Project) (*gits.Status, error) {
-	var pp = share.ProjectAbsolutePath(p.Name)
-	s.SetDir(pp)
-	var output, err = s.Command("git", "fetch").CombinedOutput()
+func resetDir(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Mkdir(dir, 0755)
+}
+
+func (t *syncTask) fetch() error {
+	t.session.SetDir(t.project.absPath())
+	var output, err = t.git(t.options.fetchTimeout, "fetch")
 	if err != nil {
-		return nil, fmt.Errorf("git fetch error: %+v\n%s", err, output)
+		return fmt.Errorf("git fetch error: %+v\n%s", err, output)
 	}
-	output, err = s.Command("git", "status", "--porcelain=v2", "--branch").CombinedOutput()
+	return nil
+}
+
+func addRemotes(s *gits.Session, p *Project) error {
+	if len(p.Remotes) == 0 {
+		return nil
+	}
+	s.SetDir(p.absPath())
+	var output, err = s.CombinedOutput("remote")
 	if err != nil {
-		return nil, fmt.Errorf("git status error: %+v", err)
+		return fmt.Errorf("git remote error: %+v\n%s", err, output)
 	}
-	var status = gits.NewStatus(pp)
-	if err = status.Parse(output); err != nil {
-		return nil, fmt.Errorf("parse git status error: %+v", err)
+	var exists = make(map[string]struct{})
+	for _, name := range strings.Fields(string(output)) {
+		exists[name] = struct{}{}
 	}
-	output, err = s.Command("git", "describe", "--tags").CombinedOutput()
-	if err == nil {
-		status.SetTag(strings.TrimRight(string(output), "\n"))
+	for _, r := range p.Remotes {
+		if _, ok := exists[r.Name]; ok {
+			continue
+		}
+		output, err = s.CombinedOutput("remote", "add", r.Name, r.URL)
+		if err != nil {
+			return fmt.Errorf("git remote add %s error: %+v\n%s", r.Name, err, output)
+		}
 	}
-	return status, nil
+	return nil
 }
 
-func loadProjects() ([]Project, error) {
-	var buff, err = ioutil.ReadFile(share.ConfigAbsoluteFile())
-	if err != nil {
-		return nil, fmt.Errorf("loadProjects readFile error: %+v", err)
+func pullProject(t *syncTask) error {
+	var s, p, r = t.session, t.project, t.result
+	var start = time.Now()
+	if err := t.fetch(); err != nil {
+		return fmt.Errorf("status project error: %+v", err)
 	}
-	var projects []Project
-	if err = yaml.Unmarshal(buff, &projects); err != nil {
-		return nil, fmt.Errorf("loadProjects unmarshal error: %+v", err)
+	t.emit(&syncEvent{Type: eventFetch, DurationMS: sinceMS(start)})
+	var status, err = statusProject(s, p, false)
+	if err != nil {
+		return fmt.Errorf("status project error: %+v", err)
 	}
-	return projects, nil
-}
 
-func saveProjects(projects []Project) error {
-	var buf, err = yaml.Marshal(projects)
+	defer func() {
+		t.emitStatus(status)
+	}()
+
+	if p.Ref != "" {
+		pull, warning, err := checkPinnedRef(s, p, status)
+		if err != nil {
+			return err
+		}
+		if warning != "" {
+			t.warn("%s", warning)
+			r.skip("moved off pinned ref %s", p.Ref)
+		}
+		if !pull {
+			return nil
+		}
+	}
+	var strategy, autostash = p.pullStrategy(), p.autostash()
+	var decision = status.PullDecision(pullPolicy(strategy, autostash))
+	if !decision.Allowed {
+		if !decision.Has(gits.ReasonUpToDate) {
+			t.emit(&syncEvent{
+				Type:    eventWarning,
+				Message: fmt.Sprintf("Skip pulling with %s: %s.", strategy, decision),
+				Reasons: decision.Reasons,
+			})
+			r.skip("%s: %s", strategy, decision)
+		}
+		return nil
+	}
+	args, err := pullArgs(strategy, autostash)
 	if err != nil {
-		return fmt.Errorf("saveProjects marshal error: %+v", err)
+		return err
 	}
-	if err = ioutil.WriteFile(share.ConfigAbsoluteFile(), buf, 0755); err != nil {
-		return fmt.Errorf("saveProjects writeFile error: %+v", err)
+	start = time.Now()
+	s.SetDir(p.absPath())
+	output, err := t.git(t.options.fetchTimeout, args...)
+	if err != nil {
+		return fmt.Errorf("git pull project error: %+v\n%s", err, output)
 	}
+	if err = t.updateSubmodules(status); err != nil {
+		return err
+	}
+	var oldCommit = status.Commit()
+	status, err = statusProject(s, p, false)
+	if err != nil {
+		return fmt.Errorf("status project agin error: %+v", err)
+	}
+	status.SetNewPull()
+	t.emit(&syncEvent{
+		Type:       eventPull,
+		DurationMS: sinceMS(start),
+		Detail:     strategy,
+		OldCommit:  oldCommit,
+		NewCommit:  status.Commit(),
+	})
+	r.action = actionPulled
+	r.detail = strategy
 	return nil
 }
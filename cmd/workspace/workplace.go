@@ -10,5 +10,13 @@ var Command = &cli.Command{
 		initCommand,
 		syncCommand,
 		addCommand,
+		removeCommand,
+		listCommand,
+		statusCommand,
+		pushCommand,
+		execCommand,
+		checkoutCommand,
+		lockCommand,
+		bundleCommand,
 	},
 }
@@ -3,7 +3,6 @@ module github.com/pinealctx/renault
 go 1.16
 
 require (
-	github.com/codeskyblue/go-sh v0.0.0-20200712050446-30169cf553fe
 	github.com/gookit/color v1.4.2
 	github.com/panjf2000/ants/v2 v2.4.6
 	github.com/urfave/cli/v2 v2.3.0
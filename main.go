@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/pinealctx/renault/cmd/project"
 	"github.com/pinealctx/renault/cmd/workspace"
 	"github.com/pinealctx/renault/pkg/share"
 	"github.com/urfave/cli/v2"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 )
 
 func main() {
@@ -20,29 +24,46 @@ func main() {
 		&cli.StringFlag{
 			Name:    "workspace",
 			Aliases: []string{"w"},
-			Usage:   "Specify the workspace.",
+			Usage:   "Specify the workspace, default to the nearest ancestor containing .renault.",
+			EnvVars: []string{"RENAULT_WORKSPACE"},
 		},
 	}
 	app.Commands = cli.Commands{
 		project.Command,
 		workspace.Command,
 	}
-	var err = app.Run(os.Args)
+	// git and exec commands run in their own process group, which does not receive the Ctrl-C of the terminal,
+	// cancel the context to kill them, then restore the default handler so that a second Ctrl-C exits at once
+	var ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	var err = app.RunContext(ctx, os.Args)
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 }
 
 func beforeAction(c *cli.Context) error {
 	var w = c.String("workspace")
 	if w != "" {
-		share.PWD = w
+		var abs, err = filepath.Abs(w)
+		if err != nil {
+			return fmt.Errorf("workspace abs path error: %+v", err)
+		}
+		share.PWD = abs
 		return nil
 	}
 	var pwd, err = os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getwd error: %+v", err)
 	}
+	if root, ok := share.FindWorkspace(pwd); ok {
+		share.PWD = root
+		return nil
+	}
 	share.PWD = pwd
 	return nil
 }
@@ -0,0 +1,111 @@
+package gits
+
+import "strings"
+
+type Reason string
+
+const (
+	ReasonUpToDate   Reason = "behind=0"
+	ReasonNoCommits  Reason = "ahead=0"
+	ReasonDiverged   Reason = "diverged"
+	ReasonDirtyIndex Reason = "dirty-index"
+	ReasonModified   Reason = "modified"
+	ReasonUntracked  Reason = "untracked"
+	ReasonUnmerged   Reason = "unmerged"
+	ReasonNoUpstream Reason = "no-upstream"
+	ReasonDetached   Reason = "detached"
+)
+
+type Decision struct {
+	Allowed bool     `json:"allowed" yaml:"allowed"`
+	Reasons []Reason `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+}
+
+type PullPolicy struct {
+	Force          bool
+	AllowDiverged  bool
+	AllowDirty     bool
+	AllowUntracked bool
+}
+
+func newDecision(reasons ...Reason) Decision {
+	return Decision{Allowed: len(reasons) == 0, Reasons: reasons}
+}
+
+func (d Decision) Has(reason Reason) bool {
+	for _, r := range d.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func (d Decision) Only(reason Reason) bool {
+	return len(d.Reasons) == 1 && d.Reasons[0] == reason
+}
+
+func (d Decision) String() string {
+	if d.Allowed {
+		return "allowed"
+	}
+	var reasons = make([]string, 0, len(d.Reasons))
+	for _, r := range d.Reasons {
+		reasons = append(reasons, string(r))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func (status *Status) PullDecision(policy PullPolicy) Decision {
+	if status.branch == "(detached)" {
+		return newDecision(ReasonDetached)
+	}
+	if status.upstream == "" {
+		return newDecision(ReasonNoUpstream)
+	}
+	if status.behind <= 0 {
+		return newDecision(ReasonUpToDate)
+	}
+	if policy.Force {
+		return newDecision()
+	}
+	var reasons []Reason
+	if status.ahead > 0 && !policy.AllowDiverged {
+		reasons = append(reasons, ReasonDiverged)
+	}
+	if status.IsDirty() && !policy.AllowDirty {
+		reasons = append(reasons, ReasonDirtyIndex)
+	}
+	if status.hasModified() && !policy.AllowDirty {
+		reasons = append(reasons, ReasonModified)
+	}
+	if status.unTracked > 0 && !policy.AllowUntracked {
+		reasons = append(reasons, ReasonUntracked)
+	}
+	if status.hasUnmerged() {
+		reasons = append(reasons, ReasonUnmerged)
+	}
+	return newDecision(reasons...)
+}
+
+func (status *Status) PushDecision(force bool) Decision {
+	if status.branch == "(detached)" {
+		return newDecision(ReasonDetached)
+	}
+	var reasons []Reason
+	if status.upstream == "" {
+		reasons = append(reasons, ReasonNoUpstream)
+	} else if status.ahead <= 0 {
+		return newDecision(ReasonNoCommits)
+	}
+	if force {
+		return newDecision(reasons...)
+	}
+	if status.behind > 0 {
+		reasons = append(reasons, ReasonDiverged)
+	}
+	if status.IsDirty() {
+		reasons = append(reasons, ReasonDirtyIndex)
+	}
+	return newDecision(reasons...)
+}
@@ -0,0 +1,74 @@
+package gits
+
+import (
+	"reflect"
+	"testing"
+)
+
+func parseStatus(t *testing.T, output string) *Status {
+	var status = NewStatus(t.TempDir())
+	if err := status.Parse([]byte(output)); err != nil {
+		t.Fatal(err)
+	}
+	return status
+}
+
+const (
+	headerBehind   = "# branch.oid 1234567890\n# branch.head master\n# branch.upstream origin/master\n# branch.ab +0 -2\n"
+	headerDiverged = "# branch.oid 1234567890\n# branch.head master\n# branch.upstream origin/master\n# branch.ab +1 -2\n"
+	headerAhead    = "# branch.oid 1234567890\n# branch.head master\n# branch.upstream origin/master\n# branch.ab +3 -0\n"
+	headerNew      = "# branch.oid 1234567890\n# branch.head feat\n"
+	stagedLine     = "1 M. N... 100644 100644 100644 abc abc a.go\n"
+	modifiedLine   = "1 .M N... 100644 100644 100644 abc abc b.go\n"
+	untrackedLine  = "? c.go\n"
+)
+
+func TestPullDecision(t *testing.T) {
+	var cases = []struct {
+		output string
+		policy PullPolicy
+		want   Decision
+	}{
+		{headerAhead, PullPolicy{}, Decision{Reasons: []Reason{ReasonUpToDate}}},
+		{headerNew, PullPolicy{}, Decision{Reasons: []Reason{ReasonNoUpstream}}},
+		{headerBehind, PullPolicy{}, Decision{Allowed: true}},
+		{headerDiverged + stagedLine + modifiedLine + untrackedLine, PullPolicy{},
+			Decision{Reasons: []Reason{ReasonDiverged, ReasonDirtyIndex, ReasonModified, ReasonUntracked}}},
+		{headerDiverged + stagedLine + untrackedLine, PullPolicy{AllowDiverged: true, AllowDirty: true, AllowUntracked: true},
+			Decision{Allowed: true}},
+		{headerDiverged + stagedLine, PullPolicy{Force: true}, Decision{Allowed: true}},
+		{headerBehind + "u UU N... 100644 100644 100644 100644 a b c d.go\n", PullPolicy{AllowDirty: true},
+			Decision{Reasons: []Reason{ReasonUnmerged}}},
+	}
+	for i, c := range cases {
+		var got = parseStatus(t, c.output).PullDecision(c.policy)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("case %d: got %+v, want %+v", i, got, c.want)
+		}
+	}
+}
+
+func TestPushDecision(t *testing.T) {
+	var cases = []struct {
+		output string
+		force  bool
+		want   Decision
+	}{
+		{headerAhead, false, Decision{Allowed: true}},
+		{headerBehind, false, Decision{Reasons: []Reason{ReasonNoCommits}}},
+		{headerDiverged + stagedLine, false, Decision{Reasons: []Reason{ReasonDiverged, ReasonDirtyIndex}}},
+		{headerDiverged + stagedLine, true, Decision{Allowed: true}},
+		{headerNew, false, Decision{Reasons: []Reason{ReasonNoUpstream}}},
+		{"# branch.oid 1234567890\n# branch.head (detached)\n", true, Decision{Reasons: []Reason{ReasonDetached}}},
+	}
+	for i, c := range cases {
+		var status = parseStatus(t, c.output)
+		var got = status.PushDecision(c.force)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("case %d: got %+v, want %+v", i, got, c.want)
+		}
+		if status.CanPush(c.force) != c.want.Allowed {
+			t.Fatalf("case %d: CanPush does not match the decision", i)
+		}
+	}
+}
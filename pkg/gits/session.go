@@ -0,0 +1,87 @@
+package gits
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pinealctx/renault/pkg/procs"
+	"os/exec"
+	"time"
+)
+
+type Session struct {
+	ctx     context.Context
+	dir     string
+	timeout time.Duration
+}
+
+func NewSession(ctx context.Context) *Session {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Session{ctx: ctx}
+}
+
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+func (s *Session) SetDir(dir string) *Session {
+	s.dir = dir
+	return s
+}
+
+func (s *Session) SetTimeout(timeout time.Duration) *Session {
+	s.timeout = timeout
+	return s
+}
+
+func (s *Session) WithTimeout(timeout time.Duration) *Session {
+	var c = *s
+	c.timeout = timeout
+	return &c
+}
+
+func (s *Session) Run(args ...string) error {
+	var _, err = s.exec(args, func(cmd *exec.Cmd, buf *bytes.Buffer) {})
+	return err
+}
+
+func (s *Session) Output(args ...string) ([]byte, error) {
+	return s.exec(args, func(cmd *exec.Cmd, buf *bytes.Buffer) {
+		cmd.Stdout = buf
+	})
+}
+
+func (s *Session) CombinedOutput(args ...string) ([]byte, error) {
+	return s.exec(args, func(cmd *exec.Cmd, buf *bytes.Buffer) {
+		cmd.Stdout = buf
+		cmd.Stderr = buf
+	})
+}
+
+func (s *Session) exec(args []string, capture func(cmd *exec.Cmd, buf *bytes.Buffer)) ([]byte, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	var ctx, cancel = s.ctx, context.CancelFunc(func() {})
+	if s.timeout > 0 {
+		ctx, cancel = context.WithTimeout(s.ctx, s.timeout)
+	}
+	defer cancel()
+	var cmd = exec.Command("git", args...)
+	cmd.Dir = s.dir
+	var buf bytes.Buffer
+	capture(cmd, &buf)
+	var err = procs.Run(ctx, cmd)
+	var output = buf.Bytes()
+	switch {
+	case err == nil:
+		return output, nil
+	case s.ctx.Err() != nil:
+		return output, s.ctx.Err()
+	case ctx.Err() == context.DeadlineExceeded:
+		return output, fmt.Errorf("timeout after %s", s.timeout)
+	}
+	return output, err
+}
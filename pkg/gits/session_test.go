@@ -0,0 +1,46 @@
+package gits
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionOutput(t *testing.T) {
+	var output, err = NewSession(context.Background()).SetDir(t.TempDir()).Output("--version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(output), "git version") {
+		t.Errorf("unexpected output %q", output)
+	}
+}
+
+func TestSessionCanceled(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := NewSession(ctx).Run("--version"); err != context.Canceled {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
+
+func TestSessionTimeout(t *testing.T) {
+	var s = NewSession(context.Background()).SetDir(t.TempDir()).SetTimeout(50 * time.Millisecond)
+	var err = s.Run("-c", "alias.wait=!sleep 5", "wait")
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("want timeout error, got %v", err)
+	}
+}
+
+func TestSessionTimeoutForked(t *testing.T) {
+	var s = NewSession(context.Background()).SetDir(t.TempDir()).SetTimeout(50 * time.Millisecond)
+	var start = time.Now()
+	var _, err = s.CombinedOutput("-c", "alias.wait=!sh -c 'sleep 5 & wait'", "wait")
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("want timeout error, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("timeout returned after %s, the forked child was not killed", d)
+	}
+}
@@ -24,6 +24,29 @@ type Status struct {
 	staged    gitArea
 	newPull   bool
 	tag       string
+	stashes   int
+	submodule subArea
+}
+
+type Info struct {
+	Branch    string `json:"branch"`
+	Commit    string `json:"commit"`
+	Upstream  string `json:"upstream,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	Ahead     int    `json:"ahead"`
+	Behind    int    `json:"behind"`
+	Staged    int    `json:"staged"`
+	Modified  int    `json:"modified"`
+	UnTracked int    `json:"untracked"`
+	Unmerged  int    `json:"unmerged"`
+	Stashes   int    `json:"stashes,omitempty"`
+	Dirty     bool   `json:"dirty"`
+	NewPull   bool   `json:"new_pull"`
+
+	Submodules         int `json:"submodules,omitempty"`
+	SubmoduleCommits   int `json:"submodule_commits,omitempty"`
+	SubmoduleModified  int `json:"submodule_modified,omitempty"`
+	SubmoduleUntracked int `json:"submodule_untracked,omitempty"`
 }
 
 func NewStatus(workplace string) *Status {
@@ -57,6 +80,7 @@ func (status *Status) Fmt() string {
 		aheadArrow     = "↑"
 		behindArrow    = "↓"
 		newPullGlyph   = "🔥"
+		subCommitGlyph = "+"
 	)
 
 	branchFmt := color.New(color.FgBlue)
@@ -72,6 +96,7 @@ func (status *Status) Fmt() string {
 	unmergedFmt := color.New(color.FgCyan)
 
 	newPullFmt := color.New(color.FgRed)
+	submoduleFmt := color.New(color.FgMagenta)
 
 	var buf bytes.Buffer
 	buf.WriteString(branchFmt.Sprint(status.branch))
@@ -95,6 +120,20 @@ func (status *Status) Fmt() string {
 		buf.WriteString(unTrackedFmt.Sprint(unTrackedGlyph))
 		buf.WriteRune(' ')
 	}
+	if status.submodule.changed > 0 {
+		var parts = []string{"sub"}
+		if status.submodule.commit > 0 {
+			parts = append(parts, subCommitGlyph+strconv.Itoa(status.submodule.commit))
+		}
+		if status.submodule.modified > 0 {
+			parts = append(parts, modifiedGlyph+strconv.Itoa(status.submodule.modified))
+		}
+		if status.submodule.untracked > 0 {
+			parts = append(parts, unTrackedGlyph+strconv.Itoa(status.submodule.untracked))
+		}
+		buf.WriteString(submoduleFmt.Sprint(strings.Join(parts, " ")))
+		buf.WriteRune(' ')
+	}
 	if status.hasUnmerged() {
 		buf.WriteString(unmergedFmt.Sprint(unmergedGlyph))
 		buf.WriteRune(' ')
@@ -115,52 +154,101 @@ func (status *Status) Fmt() string {
 	return buf.String()
 }
 
-func (status *Status) CanPull(force bool) bool {
-	if status.behind <= 0 {
-		return false
-	}
-	if force {
-		return true
-	}
-	if status.ahead > 0 {
-		return false
-	}
-	if status.IsDirty() {
-		return false
-	}
-	if status.unTracked > 0 {
-		return false
-	}
-	if status.hasModified() {
-		return false
+func (status *Status) Info() Info {
+	return Info{
+		Branch:    status.branch,
+		Commit:    status.commit,
+		Upstream:  status.upstream,
+		Tag:       status.tag,
+		Ahead:     status.ahead,
+		Behind:    status.behind,
+		Staged:    status.staged.count(),
+		Modified:  status.unStaged.count(),
+		UnTracked: status.unTracked,
+		Unmerged:  status.unmerged,
+		Stashes:   status.stashes,
+		Dirty:     status.IsDirty(),
+		NewPull:   status.newPull,
+
+		Submodules:         status.submodule.changed,
+		SubmoduleCommits:   status.submodule.commit,
+		SubmoduleModified:  status.submodule.modified,
+		SubmoduleUntracked: status.submodule.untracked,
 	}
-	return true
+}
+
+func (status *Status) CanPull(force bool) bool {
+	return status.PullDecision(PullPolicy{Force: force}).Allowed
 }
 
 func (status *Status) CanPush(force bool) bool {
-	if status.ahead <= 0 {
-		return false
-	}
-	if force {
-		return true
-	}
-	if status.behind > 0 {
-		return false
-	}
-	if status.IsDirty() {
-		return false
-	}
-	return true
+	return status.PushDecision(force).Allowed
 }
 
 func (status *Status) IsDirty() bool {
 	return status.staged.hasChanged()
 }
 
+func (status *Status) HasChanges() bool {
+	return status.IsDirty() || status.hasModified() || status.unTracked > 0 || status.hasUnmerged()
+}
+
 func (status *Status) Branch() string {
 	return status.branch
 }
 
+func (status *Status) Commit() string {
+	return status.commit
+}
+
+func (status *Status) Tag() string {
+	return status.tag
+}
+
+func (status *Status) Upstream() string {
+	return status.upstream
+}
+
+func (status *Status) Ahead() int {
+	return status.ahead
+}
+
+func (status *Status) Behind() int {
+	return status.behind
+}
+
+func (status *Status) Staged() int {
+	return status.staged.count()
+}
+
+func (status *Status) Modified() int {
+	return status.unStaged.count()
+}
+
+func (status *Status) UnTracked() int {
+	return status.unTracked
+}
+
+func (status *Status) Unmerged() int {
+	return status.unmerged
+}
+
+func (status *Status) Submodules() int {
+	return status.submodule.changed
+}
+
+func (status *Status) SubmoduleCommits() int {
+	return status.submodule.commit
+}
+
+func (status *Status) SubmoduleModified() int {
+	return status.submodule.modified
+}
+
+func (status *Status) SubmoduleUntracked() int {
+	return status.submodule.untracked
+}
+
 func (status *Status) SetNewPull() {
 	status.newPull = true
 }
@@ -169,6 +257,14 @@ func (status *Status) SetTag(tag string) {
 	status.tag = tag
 }
 
+func (status *Status) SetStashes(n int) {
+	status.stashes = n
+}
+
+func (status *Status) Stashes() int {
+	return status.stashes
+}
+
 func (status *Status) hasUnmerged() bool {
 	if status.unmerged > 0 {
 		return true
@@ -200,6 +296,7 @@ func (status *Status) parseLine(line string) {
 			_ = status.parseRenamedFile(s)
 		case "u":
 			status.unmerged++
+			_ = status.parseUnmergedFile(s)
 		case "?":
 			status.unTracked++
 		}
@@ -245,8 +342,8 @@ func (status *Status) parseTrackedFile(s *bufio.Scanner) error {
 		switch index {
 		case 0: // xy
 			status.parseXY(s.Text())
-		default:
-			continue
+		case 1: // sub
+			status.parseSub(s.Text())
 		}
 		index++
 	}
@@ -281,6 +378,41 @@ func (status *Status) parseXY(xy string) {
 	}
 }
 
+// parseSub parses the submodule state, "N..." for a plain file or
+// "S<c><m><u>" for a submodule with new commits, modified or untracked content.
+func (status *Status) parseSub(sub string) {
+	if len(sub) != 4 || sub[0] != 'S' {
+		return
+	}
+	var changed bool
+	if sub[1] == 'C' {
+		status.submodule.commit++
+		changed = true
+	}
+	if sub[2] == 'M' {
+		status.submodule.modified++
+		changed = true
+	}
+	if sub[3] == 'U' {
+		status.submodule.untracked++
+		changed = true
+	}
+	if changed {
+		status.submodule.changed++
+	}
+}
+
+func (status *Status) parseUnmergedFile(s *bufio.Scanner) error {
+	var index int
+	for s.Scan() {
+		if index == 1 { // sub, the conflict xy is not a staged or unstaged change
+			status.parseSub(s.Text())
+		}
+		index++
+	}
+	return nil
+}
+
 func (status *Status) parseRenamedFile(s *bufio.Scanner) error {
 	return status.parseTrackedFile(s)
 }
@@ -313,6 +445,17 @@ func (a *gitArea) hasChanged() bool {
 	return changed
 }
 
+func (a *gitArea) count() int {
+	return a.added + a.deleted + a.modified + a.copied + a.renamed
+}
+
+type subArea struct {
+	changed   int
+	commit    int
+	modified  int
+	untracked int
+}
+
 func consumeNext(s *bufio.Scanner) string {
 	if s.Scan() {
 		return s.Text()
@@ -0,0 +1,34 @@
+package gits
+
+import (
+	"testing"
+)
+
+func TestParseSubmodule(t *testing.T) {
+	var status = parseStatus(t, headerBehind+
+		"1 .M SC.. 160000 160000 160000 abc abc libs/a\n"+
+		"1 .M S.MU 160000 160000 160000 abc abc libs/b\n"+
+		"1 .. S... 160000 160000 160000 abc abc libs/c\n"+
+		modifiedLine)
+	if status.Submodules() != 2 || status.SubmoduleCommits() != 1 || status.SubmoduleModified() != 1 || status.SubmoduleUntracked() != 1 {
+		t.Errorf("unexpected submodule state: %+v", status.Info())
+	}
+	if status.Modified() != 3 {
+		t.Errorf("modified = %d, want 3", status.Modified())
+	}
+}
+
+func TestParseUnmerged(t *testing.T) {
+	var status = parseStatus(t, headerBehind+
+		"u AA N... 100644 100644 100644 100644 abc abc abc a.go\n"+
+		"u UU SCM. 160000 160000 160000 160000 abc abc abc libs/a\n")
+	if status.Unmerged() != 2 {
+		t.Errorf("unmerged = %d, want 2", status.Unmerged())
+	}
+	if status.IsDirty() || status.Staged() != 0 || status.Modified() != 0 {
+		t.Errorf("conflicts must not count as staged or modified: %+v", status.Info())
+	}
+	if status.Submodules() != 1 || status.SubmoduleCommits() != 1 || status.SubmoduleModified() != 1 {
+		t.Errorf("unexpected submodule state: %+v", status.Info())
+	}
+}
@@ -0,0 +1,25 @@
+package procs
+
+import (
+	"context"
+	"os/exec"
+)
+
+// Run starts the command in its own process group and kills the whole group once ctx is done,
+// so that children holding the output pipes, eg: git-remote-https or ssh, can not keep Wait blocked.
+func Run(ctx context.Context, cmd *exec.Cmd) error {
+	setGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	var done = make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killGroup(cmd)
+		case <-done:
+		}
+	}()
+	return cmd.Wait()
+}
@@ -0,0 +1,25 @@
+package procs
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestRunKillsGroup(t *testing.T) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var cmd = exec.Command("sh", "-c", "sleep 5 & wait")
+	var buf bytes.Buffer
+	cmd.Stdout = &buf
+	cmd.Stderr = &buf
+	var start = time.Now()
+	if err := Run(ctx, cmd); err == nil {
+		t.Errorf("want killed error, got nil")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Run returned after %s, the forked child kept the pipe open", d)
+	}
+}
@@ -0,0 +1,11 @@
+package procs
+
+import (
+	"os/exec"
+)
+
+func setGroup(*exec.Cmd) {}
+
+func killGroup(cmd *exec.Cmd) {
+	_ = cmd.Process.Kill()
+}
@@ -1,10 +1,17 @@
 package share
 
-import "path"
+import (
+	"os"
+	"path"
+	"path/filepath"
+)
 
 const (
 	RenaultPath              = ".renault"
 	RenaultProjectConfigPath = "project.yaml"
+	RenaultLockPath          = "lock.yaml"
+	RenaultBundlePath        = "bundle.yaml"
+	RenaultUserConfigPath    = "renault/config.yaml"
 )
 
 var (
@@ -19,6 +26,37 @@ func ConfigAbsoluteFile() string {
 	return path.Join(PWD, RenaultPath, RenaultProjectConfigPath)
 }
 
+func LockAbsoluteFile() string {
+	return path.Join(PWD, RenaultPath, RenaultLockPath)
+}
+
+func BundleAbsoluteFile() string {
+	return path.Join(PWD, RenaultPath, RenaultBundlePath)
+}
+
 func ProjectAbsolutePath(p string) string {
 	return path.Join(PWD, p)
 }
+
+func UserConfigFile() (string, error) {
+	var dir, err = os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, RenaultUserConfigPath), nil
+}
+
+func FindWorkspace(dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	for {
+		var info, err = os.Stat(filepath.Join(dir, RenaultPath))
+		if err == nil && info.IsDir() {
+			return dir, true
+		}
+		var parent = filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
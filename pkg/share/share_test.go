@@ -0,0 +1,29 @@
+package share
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindWorkspace(t *testing.T) {
+	var root = t.TempDir()
+	var sub = filepath.Join(root, "hermes", "user", "internal")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FindWorkspace(sub); ok {
+		t.Fatal("unexpected workspace found")
+	}
+	if err := os.Mkdir(filepath.Join(root, RenaultPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var dir, ok = FindWorkspace(sub)
+	if !ok || dir != root {
+		t.Fatalf("unexpected workspace: %s %v", dir, ok)
+	}
+	dir, ok = FindWorkspace(root)
+	if !ok || dir != root {
+		t.Fatalf("unexpected workspace: %s %v", dir, ok)
+	}
+}